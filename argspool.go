@@ -7,12 +7,17 @@ import (
 )
 
 type args struct {
-	srcName string //debug
 	dstType reflect.Type
 	srcType reflect.Type
 	dstAddr unsafe.Pointer
 	srcAddr unsafe.Pointer
-	*offsetAndFunc
+
+	// 字段路径, 出错时用于定位字段
+	parent *args
+	seg    segment
+
+	// 字段tag里设置的合并策略
+	strategy Strategy
 }
 
 var argsPool = &sync.Pool{
@@ -20,3 +25,16 @@ var argsPool = &sync.Pool{
 		return &args{}
 	},
 }
+
+// 从对象池里取出参数, 并清空上次使用留下的数据
+func newArgs(parent *args, dstType, srcType reflect.Type, dstAddr, srcAddr unsafe.Pointer) *args {
+	a := argsPool.Get().(*args)
+	*a = args{
+		dstType: dstType,
+		srcType: srcType,
+		dstAddr: dstAddr,
+		srcAddr: srcAddr,
+		parent:  parent,
+	}
+	return a
+}
@@ -3,74 +3,245 @@ package dcopy
 import (
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
-var (
-	cacheAllFunc map[dstSrcType]*allFieldFunc = make(map[dstSrcType]*allFieldFunc)
-	rdlock       sync.RWMutex
-	OpenCache    bool
-)
+// dCopy.Cache()使用的缓存
+var sharedCache = newPlanCache(defaultCacheSize)
 
-type dstSrcType struct {
-	dst reflect.Type
-	src reflect.Type
+// 缓存的key, 除了dst和src的类型, 还包含会影响拷贝结果的配置
+// 比如使用RegisterTagName("copy")编译的计划不能给没有tag的拷贝使用
+type planKey struct {
+	dst   reflect.Type
+	src   reflect.Type
+	opts  optionsKey
+	depth int // 设置了MaxDepth时, 同一个类型对在不同的层次计划不一样
 }
 
-type allFieldFunc struct {
-	fieldFuncs []*offsetAndFunc
+// 配置的指纹, 转换函数和回调使用options.funcsVersion区分
+// 不能使用map的地址, map释放之后地址可能被新的map使用
+type optionsKey struct {
+	tagName         string
+	depthLimited    bool
+	maxDepth        int
+	overflow        OverflowMode
+	convertString   bool
+	funcsVersion    uint64
+	globalVersion   uint64
+	ignoreZero      bool
+	strategy        Strategy
+	matcher         FieldMatcher
+	continueOnError bool
+	noTrackRef      bool
 }
 
-type offsetAndFunc struct {
-	srcKind   reflect.Kind
-	dstOffset int
-	srcOffset int
+// 默认最多缓存的计划个数
+const defaultCacheSize = 1024
+
+// 缓存的统计信息, 可以导出到监控系统
+type PlanCacheStats struct {
+	Hits      uint64 // 从缓存里取到计划的次数
+	Misses    uint64 // 缓存里没有计划的次数
+	Builds    uint64 // 编译计划的次数
+	Evictions uint64 // 缓存满了之后淘汰的计划个数
+	Len       int    // 当前缓存的计划个数
+	Size      int    // 最多缓存的计划个数, 0表示不限制
+}
 
-	set setFunc
+type planEntry struct {
+	key  planKey
+	plan *plan
+	ref  int32 // 最近使用过, 淘汰时跳过一次
 }
 
-func saveToCache(a *args, fieldFunc *allFieldFunc) {
-	rdlock.Lock()
-	defer rdlock.Unlock()
+// 使用clock算法淘汰, 读的时候只需要读锁
+type planCache struct {
+	sync.RWMutex
+	plans map[planKey]*planEntry
+	ring  []*planEntry
+	hand  int
+	size  int
 
-	cacheAllFunc[dstSrcType{dst: a.dstType, src: a.srcType}] = fieldFunc
+	hits      uint64
+	misses    uint64
+	builds    uint64
+	evictions uint64
 }
 
-func getSetFromCacheAndRun(a *args) (exist bool) {
-	rdlock.RLock()
-	cacheFunc, ok := cacheAllFunc[dstSrcType{dst: a.dstType, src: a.srcType}]
-	if !ok {
-		rdlock.RUnlock()
-		return ok
+// size是最多缓存的计划个数, 0表示不限制
+func newPlanCache(size int) *planCache {
+	return &planCache{plans: make(map[planKey]*planEntry), size: size}
+}
+
+func (c *planCache) get(key planKey) *plan {
+	c.RLock()
+	e := c.plans[key]
+	c.RUnlock()
+
+	if e == nil {
+		atomic.AddUint64(&c.misses, 1)
+		return nil
 	}
-	rdlock.RUnlock()
 
-	cacheFunc.do(0, a.dstAddr, a.srcAddr)
-	return true
+	if atomic.LoadInt32(&e.ref) == 0 {
+		atomic.StoreInt32(&e.ref, 1)
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return e.plan
 }
 
-func add(addr unsafe.Pointer, offset int) unsafe.Pointer {
-	return unsafe.Pointer(uintptr(addr) + uintptr(offset))
+// 和get一样, 不修改统计信息和最近使用的标记
+func (c *planCache) peek(key planKey) *plan {
+	c.RLock()
+	defer c.RUnlock()
+	if e := c.plans[key]; e != nil {
+		return e.plan
+	}
+	return nil
 }
 
-func newAllFieldFunc() *allFieldFunc {
-	return &allFieldFunc{fieldFuncs: make([]*offsetAndFunc, 0, 8)}
+func (c *planCache) save(key planKey, p *plan) {
+	c.Lock()
+	defer c.Unlock()
+
+	// 其他goroutine已经保存了相同的计划
+	if _, ok := c.plans[key]; ok {
+		return
+	}
+
+	e := &planEntry{key: key, plan: p}
+	c.plans[key] = e
+	if c.size > 0 && len(c.ring) >= c.size {
+		c.ring[c.evict()] = e
+		return
+	}
+	c.ring = append(c.ring, e)
 }
 
-func (af *allFieldFunc) append(a *args) {
-	fieldFunc := a.offsetAndFunc
-	af.fieldFuncs = append(af.fieldFuncs, fieldFunc)
+// 从hand开始找到最近没有使用过的计划, 删除之后返回它在ring里的位置
+func (c *planCache) evict() int {
+	for {
+		e := c.ring[c.hand]
+		if atomic.LoadInt32(&e.ref) != 0 {
+			atomic.StoreInt32(&e.ref, 0)
+			c.hand = (c.hand + 1) % len(c.ring)
+			continue
+		}
+
+		i := c.hand
+		delete(c.plans, e.key)
+		c.hand = (c.hand + 1) % len(c.ring)
+		atomic.AddUint64(&c.evictions, 1)
+		return i
+	}
 }
 
-func (c *allFieldFunc) do(start int, dstAddr, srcAddr unsafe.Pointer) {
-	for k, v := range c.fieldFuncs[start:] {
-		switch v.srcKind {
-		case reflect.Array, reflect.Slice:
-			c.do(k+1, add(dstAddr, v.dstOffset), add(srcAddr, v.srcOffset))
-			return
-		default:
-			//fmt.Printf("%d:%d:%p:%p:%v\n", v.dstOffset, v.srcOffset, add(dstAddr, v.dstOffset), add(srcAddr, v.srcOffset), v.srcKind)
-			v.set(add(dstAddr, v.dstOffset), add(srcAddr, v.srcOffset))
+func (c *planCache) resize(size int) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.size = size
+	for size > 0 && len(c.ring) > size {
+		i := c.evict()
+		c.ring = append(c.ring[:i], c.ring[i+1:]...)
+		if c.hand > i {
+			c.hand--
+		}
+		if c.hand >= len(c.ring) {
+			c.hand = 0
 		}
 	}
 }
+
+func (c *planCache) stats() PlanCacheStats {
+	c.RLock()
+	n, size := len(c.plans), c.size
+	c.RUnlock()
+
+	return PlanCacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Builds:    atomic.LoadUint64(&c.builds),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Len:       n,
+		Size:      size,
+	}
+}
+
+// 返回dCopy.Cache()使用的共享缓存的统计信息
+func CacheStats() PlanCacheStats {
+	return sharedCache.stats()
+}
+
+// 设置共享缓存最多缓存的计划个数, 超过时淘汰最近没有使用的计划, 0表示不限制
+// 默认是1024
+func SetCacheSize(size int) {
+	sharedCache.resize(size)
+}
+
+// 严格模式和报告需要反射拷贝里的完整检查, 不使用缓存
+// matcher不能比较时(比如FieldMatcherFunc), 或者dCopy上设置了转换函数和回调时, 也不使用缓存
+func (f *dCopy) usePlan() bool {
+	if f.cache == nil || f.strict || f.report != nil || f.callFuncs {
+		return false
+	}
+
+	if f.matcher != nil && !reflect.TypeOf(f.matcher).Comparable() {
+		return false
+	}
+
+	f.optsKey = optionsKey{
+		tagName:         f.tagName,
+		depthLimited:    f.depthLimited,
+		maxDepth:        f.maxDepth,
+		overflow:        f.overflow,
+		convertString:   f.convertString,
+		funcsVersion:    f.funcsVersion,
+		globalVersion:   atomic.LoadUint64(&globalConvertersVersion),
+		ignoreZero:      f.ignoreZero,
+		strategy:        f.strategy,
+		matcher:         f.matcher,
+		continueOnError: f.continueOnError,
+		noTrackRef:      f.noTrackRef,
+	}
+	return true
+}
+
+func (f *dCopy) planKey(dst, src reflect.Type, depth int) planKey {
+	key := planKey{dst: dst, src: src, opts: f.optsKey}
+	if f.depthLimited {
+		key.depth = depth
+	}
+	return key
+}
+
+// 从缓存里取出计划, 没有时编译一个新的
+// 多个goroutine同时编译同一个计划时, 结果是一样的, 保存哪一个都可以
+func (f *dCopy) getPlan(dst, src reflect.Type, depth int) *plan {
+	key := f.planKey(dst, src, depth)
+	if p := f.cache.get(key); p != nil {
+		return p
+	}
+	return f.buildPlan(key)
+}
+
+// 和getPlan一样, 不计入命中和未命中的次数, Precompile使用
+func (f *dCopy) warmPlan(dst, src reflect.Type) *plan {
+	key := f.planKey(dst, src, 0)
+	if p := f.cache.peek(key); p != nil {
+		return p
+	}
+	return f.buildPlan(key)
+}
+
+func (f *dCopy) buildPlan(key planKey) *plan {
+	atomic.AddUint64(&f.cache.builds, 1)
+	p := f.compile(key.dst, key.src, key.depth)
+	f.cache.save(key, p)
+	return p
+}
+
+func add(addr unsafe.Pointer, offset int) unsafe.Pointer {
+	return unsafe.Pointer(uintptr(addr) + uintptr(offset))
+}
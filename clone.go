@@ -0,0 +1,30 @@
+package dcopy
+
+import "reflect"
+
+// Clone 使用默认配置深度拷贝src, 返回的值和src类型相同
+// 导出字段里的指针, map, slice, interface都会分配新的内存, 不和src共享
+// 小写字段和Copy一样整个字段浅拷贝, 里面的指针, map, slice还是和src共享
+// 比如 v, err := dcopy.Clone(user); u := v.(*User)
+// go1.21以上可以使用CloneOf, 不需要类型断言
+func Clone(src interface{}) (interface{}, error) {
+	return defaultCopier.Clone(src)
+}
+
+// Clone 使用c的配置深度拷贝src
+func (c *Copier) Clone(src interface{}) (interface{}, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	typ := reflect.TypeOf(src)
+	// 接口里的值不能取地址, 先放到可以取地址的变量里
+	srcPtr := reflect.New(typ)
+	srcPtr.Elem().Set(reflect.ValueOf(src))
+
+	dstPtr := reflect.New(typ)
+	if err := c.newCopy(dstPtr.Interface(), srcPtr.Interface()).Do(); err != nil {
+		return nil, err
+	}
+	return dstPtr.Elem().Interface(), nil
+}
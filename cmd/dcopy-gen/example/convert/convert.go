@@ -0,0 +1,40 @@
+// Package convert 演示dcopy-gen的-overflow和-convert-string, 这些转换生成的代码调用dcopy.Copy
+package convert
+
+//go:generate go run github.com/antlabs/dcopy/cmd/dcopy-gen -overflow saturate -convert-string -test
+
+//dcopy:gen Target Source
+
+type Inner struct {
+	Code  string
+	Ratio float32
+}
+
+type InnerDTO struct {
+	Code  int
+	Ratio int8
+}
+
+type Source struct {
+	ID     string
+	Age    int
+	Score  float64
+	Small  int64
+	Flag   bool
+	Same   int16
+	Nums   []string
+	Counts map[string]int64
+	Inner  Inner
+}
+
+type Target struct {
+	ID     int64
+	Age    string
+	Score  int8
+	Small  uint8
+	Flag   string
+	Same   int16
+	Nums   []int32
+	Counts map[string]string
+	Inner  InnerDTO
+}
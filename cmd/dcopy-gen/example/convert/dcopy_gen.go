@@ -0,0 +1,74 @@
+// Code generated by dcopy-gen. DO NOT EDIT.
+
+package convert
+
+import "github.com/antlabs/dcopy"
+
+// CopyTargetFromSource 把src拷贝到dst, 结果和dcopy.Copy(dst, src).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do()一样
+func CopyTargetFromSource(dst *Target, src *Source) error {
+	if dst == nil || src == nil {
+		return &dcopy.Error{Err: dcopy.ErrNotAddressable}
+	}
+	if err := dcopyGenTargetFromSource(dst, src, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+func dcopyGenTargetFromSource(dst *Target, src *Source, depth int) error {
+	if err := dcopy.Copy(&dst.ID, &src.ID).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	if err := dcopy.Copy(&dst.Age, &src.Age).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	if err := dcopy.Copy(&dst.Score, &src.Score).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	if err := dcopy.Copy(&dst.Small, &src.Small).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	if err := dcopy.Copy(&dst.Flag, &src.Flag).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	dst.Same = src.Same
+	if src.Nums == nil {
+		dst.Nums = nil
+	} else {
+		s2 := make([]int32, len(src.Nums))
+		for i1 := range src.Nums {
+			if err := dcopy.Copy(&s2[i1], &src.Nums[i1]).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+				return err
+			}
+		}
+		dst.Nums = s2
+	}
+	if src.Counts != nil {
+		if dst.Counts == nil {
+			dst.Counts = make(map[string]string, len(src.Counts))
+		}
+		for k3, v4 := range src.Counts {
+			var nk5 string
+			nk5 = k3
+			var nv6 string
+			if err := dcopy.Copy(&nv6, &v4).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+				return err
+			}
+			dst.Counts[nk5] = nv6
+		}
+	}
+	if err := dcopyGenInnerDTOFromInner(&dst.Inner, &src.Inner, depth+1); err != nil {
+		return err
+	}
+	return nil
+}
+
+func dcopyGenInnerDTOFromInner(dst *InnerDTO, src *Inner, depth int) error {
+	if err := dcopy.Copy(&dst.Code, &src.Code).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	if err := dcopy.Copy(&dst.Ratio, &src.Ratio).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do(); err != nil {
+		return err
+	}
+	return nil
+}
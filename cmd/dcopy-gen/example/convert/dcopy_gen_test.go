@@ -0,0 +1,95 @@
+// Code generated by dcopy-gen. DO NOT EDIT.
+
+package convert
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/antlabs/dcopy"
+)
+
+func TestCopyTargetFromSource(t *testing.T) {
+	for seed := 0; seed < 5; seed++ {
+		var src Source
+		dcopyGenFill(reflect.ValueOf(&src).Elem(), seed, 0)
+
+		// dst里已经有数据, 检查合并和补丁模式
+		var got, want Target
+		dcopyGenFill(reflect.ValueOf(&got).Elem(), seed+1, 0)
+		dcopyGenFill(reflect.ValueOf(&want).Elem(), seed+1, 0)
+
+		errGot := CopyTargetFromSource(&got, &src)
+		errWant := dcopy.Copy(&want, &src).OverflowMode(dcopy.OverflowSaturate).ConvertString().Do()
+		if (errGot == nil) != (errWant == nil) {
+			t.Fatalf("seed %d: generated error %v, dcopy.Copy error %v", seed, errGot, errWant)
+		}
+
+		if errGot == nil && !reflect.DeepEqual(got, want) {
+			t.Errorf("seed %d: generated %+v, dcopy.Copy %+v", seed, got, want)
+		}
+	}
+}
+
+// 按照seed填充v, level限制递归的层次
+func dcopyGenFill(v reflect.Value, seed, level int) {
+	if level > 4 {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(seed%2 == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(seed*100 + level))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(seed*100 + level))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(seed*100+level) + 0.5)
+	case reflect.String:
+		// 一半是数字, 用于检查ConvertString
+		if seed%2 == 0 {
+			v.SetString(fmt.Sprint(seed*100 + level))
+		} else {
+			v.SetString(fmt.Sprintf("s%d_%d", seed, level))
+		}
+	case reflect.Slice:
+		n := seed % 3
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			dcopyGenFill(s.Index(i), seed+i, level+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			dcopyGenFill(v.Index(i), seed+i, level+1)
+		}
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < seed%3; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			val := reflect.New(v.Type().Elem()).Elem()
+			dcopyGenFill(key, seed+i, level+1)
+			dcopyGenFill(val, seed+i, level+1)
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Ptr:
+		if seed%4 != 0 {
+			p := reflect.New(v.Type().Elem())
+			dcopyGenFill(p.Elem(), seed, level+1)
+			v.Set(p)
+		}
+	case reflect.Interface:
+		if v.NumMethod() == 0 && seed%2 == 1 {
+			v.Set(reflect.ValueOf(fmt.Sprintf("i%d", seed)))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				dcopyGenFill(v.Field(i), seed+i, level+1)
+			}
+		}
+	}
+}
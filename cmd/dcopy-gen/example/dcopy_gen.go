@@ -0,0 +1,282 @@
+// Code generated by dcopy-gen. DO NOT EDIT.
+
+package example
+
+import "github.com/antlabs/dcopy"
+
+// CopyUserDTOFromUser 把src拷贝到dst, 结果和dcopy.Copy(dst, src).RegisterTagName("copy").MaxDepth(4).Do()一样
+func CopyUserDTOFromUser(dst *UserDTO, src *User) error {
+	if dst == nil || src == nil {
+		return &dcopy.Error{Err: dcopy.ErrNotAddressable}
+	}
+	if err := dcopyGenUserDTOFromUser(dst, src, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CopyNodeDTOFromNode 把src拷贝到dst, 结果和dcopy.Copy(dst, src).RegisterTagName("copy").MaxDepth(4).Do()一样
+func CopyNodeDTOFromNode(dst *NodeDTO, src *Node) error {
+	if dst == nil || src == nil {
+		return &dcopy.Error{Err: dcopy.ErrNotAddressable}
+	}
+	if err := dcopyGenNodeDTOFromNode(dst, src, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CopyAuditDTOFromAudit 把src拷贝到dst, 结果和dcopy.Copy(dst, src).RegisterTagName("copy").MaxDepth(4).Do()一样
+func CopyAuditDTOFromAudit(dst *AuditDTO, src *Audit) error {
+	if dst == nil || src == nil {
+		return &dcopy.Error{Err: dcopy.ErrNotAddressable}
+	}
+	if err := dcopyGenAuditDTOFromAudit(dst, src, 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+func dcopyGenUserDTOFromUser(dst *UserDTO, src *User, depth int) error {
+	if depth+1 > 4 {
+		return nil
+	}
+	dst.UserID = int(src.ID)
+	dst.Name = src.Name
+	dst.Age = int8(src.Age)
+	dst.Status = Status(src.Status)
+	if src.Tags == nil {
+		dst.Tags = nil
+	} else {
+		s2 := make([]string, len(src.Tags))
+		for i1 := range src.Tags {
+			s2[i1] = src.Tags[i1]
+		}
+		dst.Tags = s2
+	}
+	{
+		s4 := make([]int64, len(src.Scores))
+		for i3 := range src.Scores {
+			s4[i3] = int64(src.Scores[i3])
+		}
+		dst.Scores = s4
+	}
+	if src.Address == nil {
+		dst.Address = nil
+	} else {
+		p5 := new(AddressDTO)
+		if err := dcopyGenAddressDTOFromAddress(p5, src.Address, depth+1); err != nil {
+			return err
+		}
+		dst.Address = p5
+	}
+	if src.Items == nil {
+		dst.Items = nil
+	} else {
+		s7 := make([]ItemDTO, len(src.Items))
+		for i6 := range src.Items {
+			if err := dcopyGenItemDTOFromItem(&s7[i6], &src.Items[i6], depth+1); err != nil {
+				return err
+			}
+		}
+		dst.Items = s7
+	}
+	if src.Attrs != nil {
+		if dst.Attrs == nil {
+			dst.Attrs = make(map[string]int32, len(src.Attrs))
+		}
+		for k8, v9 := range src.Attrs {
+			var nk10 string
+			nk10 = k8
+			var nv11 int32
+			nv11 = int32(v9)
+			dst.Attrs[nk10] = nv11
+		}
+	}
+	if src.Groups != nil {
+		if dst.Groups == nil {
+			dst.Groups = make(map[int64][]*ItemDTO, len(src.Groups))
+		}
+		for k12, v13 := range src.Groups {
+			var nk14 int64
+			nk14 = int64(k12)
+			var nv15 []*ItemDTO
+			if v13 == nil {
+				nv15 = nil
+			} else {
+				s17 := make([]*ItemDTO, len(v13))
+				for i16 := range v13 {
+					if v13[i16] == nil {
+						s17[i16] = nil
+					} else {
+						p18 := new(ItemDTO)
+						if err := dcopyGenItemDTOFromItem(p18, v13[i16], depth+1); err != nil {
+							return err
+						}
+						s17[i16] = p18
+					}
+				}
+				nv15 = s17
+			}
+			dst.Groups[nk14] = nv15
+		}
+	}
+	if err := dcopy.Copy(&dst.Extra, &src.Extra).RegisterTagName("copy").MaxDepth(4 - (depth + 1)).Do(); err != nil {
+		return err
+	}
+	if err := dcopy.Copy(&dst.CreatedAt, &src.CreatedAt).RegisterTagName("copy").MaxDepth(4 - (depth + 1)).Do(); err != nil {
+		return err
+	}
+	if src.Labels != nil {
+		if dst.Labels == nil {
+			dst.Labels = make(map[string]string, len(src.Labels))
+		}
+		for k19, v20 := range src.Labels {
+			var nk21 string
+			nk21 = k19
+			var nv22 string
+			nv22 = v20
+			dst.Labels[nk21] = nv22
+		}
+	}
+	for i23 := 0; i23 < len(dst.Matrix) && i23 < len(src.Matrix); i23++ {
+		if src.Matrix[i23] == nil {
+			dst.Matrix[i23] = nil
+		} else {
+			s25 := make([]float64, len(src.Matrix[i23]))
+			for i24 := range src.Matrix[i23] {
+				s25[i24] = float64(src.Matrix[i23][i24])
+			}
+			dst.Matrix[i23] = s25
+		}
+	}
+	if err := dcopyGen6(&dst.Nested, &src.Nested, depth+1); err != nil {
+		return err
+	}
+	return nil
+}
+
+func dcopyGenNodeDTOFromNode(dst *NodeDTO, src *Node, depth int) error {
+	if depth+1 > 4 {
+		return nil
+	}
+	dst.Name = src.Name
+	if src.Next == nil {
+		dst.Next = nil
+	} else {
+		p26 := new(NodeDTO)
+		if err := dcopyGenNodeDTOFromNode(p26, src.Next, depth+1); err != nil {
+			return err
+		}
+		dst.Next = p26
+	}
+	if src.Children == nil {
+		dst.Children = nil
+	} else {
+		s28 := make([]*NodeDTO, len(src.Children))
+		for i27 := range src.Children {
+			if src.Children[i27] == nil {
+				s28[i27] = nil
+			} else {
+				p29 := new(NodeDTO)
+				if err := dcopyGenNodeDTOFromNode(p29, src.Children[i27], depth+1); err != nil {
+					return err
+				}
+				s28[i27] = p29
+			}
+		}
+		dst.Children = s28
+	}
+	if src.Meta != nil {
+		if dst.Meta == nil {
+			dst.Meta = make(Tree, len(src.Meta))
+		}
+		for k30, v31 := range src.Meta {
+			var nk32 string
+			nk32 = k30
+			var nv33 Tree
+			if err := dcopyGenTreeFromTree(&nv33, &v31, depth+1); err != nil {
+				return err
+			}
+			dst.Meta[nk32] = nv33
+		}
+	}
+	if src.Path == nil {
+		dst.Path = nil
+	} else {
+		s35 := make(List, len(src.Path))
+		for i34 := range src.Path {
+			if err := dcopyGenListFromList(&s35[i34], &src.Path[i34], depth+1); err != nil {
+				return err
+			}
+		}
+		dst.Path = s35
+	}
+	return nil
+}
+
+func dcopyGenAuditDTOFromAudit(dst *AuditDTO, src *Audit, depth int) error {
+	if err := dcopy.Copy(dst, src).RegisterTagName("copy").MaxDepth(4 - (depth)).Do(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func dcopyGenAddressDTOFromAddress(dst *AddressDTO, src *Address, depth int) error {
+	if depth+1 > 4 {
+		return nil
+	}
+	dst.City = src.City
+	dst.Zip = int64(src.Zip)
+	return nil
+}
+
+func dcopyGenItemDTOFromItem(dst *ItemDTO, src *Item, depth int) error {
+	if depth+1 > 4 {
+		return nil
+	}
+	dst.SKU = src.SKU
+	dst.Price = int32(int64(src.Price))
+	dst.Count = uint8(src.Count)
+	return nil
+}
+
+func dcopyGen6(dst *struct{ A, B uint16 }, src *struct{ A, B int8 }, depth int) error {
+	if depth+1 > 4 {
+		return nil
+	}
+	return nil
+}
+
+func dcopyGenTreeFromTree(dst *Tree, src *Tree, depth int) error {
+	if (*src) != nil {
+		if (*dst) == nil {
+			(*dst) = make(Tree, len((*src)))
+		}
+		for k36, v37 := range *src {
+			var nk38 string
+			nk38 = k36
+			var nv39 Tree
+			if err := dcopyGenTreeFromTree(&nv39, &v37, depth); err != nil {
+				return err
+			}
+			(*dst)[nk38] = nv39
+		}
+	}
+	return nil
+}
+
+func dcopyGenListFromList(dst *List, src *List, depth int) error {
+	if (*src) == nil {
+		(*dst) = nil
+	} else {
+		s41 := make(List, len((*src)))
+		for i40 := range *src {
+			if err := dcopyGenListFromList(&s41[i40], &(*src)[i40], depth); err != nil {
+				return err
+			}
+		}
+		(*dst) = s41
+	}
+	return nil
+}
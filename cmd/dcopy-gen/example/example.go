@@ -0,0 +1,114 @@
+// Package example 演示dcopy-gen的用法, 生成的测试检查生成的函数和dcopy.Copy的结果一样
+package example
+
+import "time"
+
+//go:generate go run github.com/antlabs/dcopy/cmd/dcopy-gen -tag copy -depth 4 -test
+
+//dcopy:gen UserDTO User
+//dcopy:gen NodeDTO Node
+//dcopy:gen AuditDTO Audit
+
+type Status int8
+
+type Address struct {
+	City string `copy:"city"`
+	Zip  int    `copy:"zip"`
+}
+
+type AddressDTO struct {
+	City string `copy:"city"`
+	Zip  int64  `copy:"zip"`
+}
+
+type Item struct {
+	SKU   string  `copy:"sku"`
+	Price float64 `copy:"price"`
+	Count int     `copy:"count"`
+}
+
+type ItemDTO struct {
+	SKU   string `copy:"sku"`
+	Price int32  `copy:"price"`
+	Count uint8  `copy:"count"`
+}
+
+type User struct {
+	ID        int64               `copy:"id"`
+	Name      string              `copy:"name"`
+	Age       int                 `copy:"age"`
+	Status    int32               `copy:"status"`
+	Password  string              `copy:"-"`
+	Remark    string              // 没有tag, 不拷贝
+	Tags      []string            `copy:"tags"`
+	Scores    [3]int              `copy:"scores"`
+	Address   *Address            `copy:"address"`
+	Items     []Item              `copy:"items"`
+	Attrs     map[string]int64    `copy:"attrs"`
+	Groups    map[int][]*Item     `copy:"groups"`
+	Extra     interface{}         `copy:"extra"`
+	CreatedAt time.Time           `copy:"created_at"`
+	Labels    map[string]string   `copy:"labels"`
+	Matrix    [][]float32         `copy:"matrix"`
+	Mismatch  string              `copy:"mismatch"`
+	Nested    struct{ A, B int8 } `copy:"nested"`
+}
+
+type UserDTO struct {
+	UserID    int                   `copy:"id"`
+	Name      string                `copy:"name"`
+	Age       int8                  `copy:"age"`
+	Status    Status                `copy:"status"`
+	Password  string                `copy:"password"`
+	Remark    string                `copy:"remark"`
+	Tags      []string              `copy:"tags"`
+	Scores    []int64               `copy:"scores"`
+	Address   *AddressDTO           `copy:"address"`
+	Items     []ItemDTO             `copy:"items"`
+	Attrs     map[string]int32      `copy:"attrs"`
+	Groups    map[int64][]*ItemDTO  `copy:"groups"`
+	Extra     interface{}           `copy:"extra"`
+	CreatedAt time.Time             `copy:"created_at"`
+	Labels    map[string]string     `copy:"labels"`
+	Matrix    [2][]float64          `copy:"matrix"`
+	Mismatch  int                   `copy:"mismatch"`
+	Nested    struct{ A, B uint16 } `copy:"nested"`
+}
+
+// 递归的类型, 受-depth限制
+type Node struct {
+	Name     string  `copy:"name"`
+	Next     *Node   `copy:"next"`
+	Children []*Node `copy:"children"`
+	Meta     Tree    `copy:"meta"`
+	Path     List    `copy:"path"`
+	weight   int
+}
+
+type NodeDTO struct {
+	Name     string     `copy:"name"`
+	Next     *NodeDTO   `copy:"next"`
+	Children []*NodeDTO `copy:"children"`
+	Meta     Tree       `copy:"meta"`
+	Path     List       `copy:"path"`
+}
+
+// 递归的map和slice类型, 生成调用自己的函数
+type Tree map[string]Tree
+
+type List []List
+
+// dst里嵌入结构体的字段会和src的字段配对, 字段上设置了合并策略, 生成的函数调用dcopy.Copy
+type Audit struct {
+	CreatedBy string   `copy:"created_by"`
+	Notes     []string `copy:"notes,append"`
+}
+
+type AuditInfo struct {
+	CreatedBy string `copy:"created_by"`
+}
+
+type AuditDTO struct {
+	AuditInfo
+	Notes []string `copy:"notes,append"`
+}
@@ -0,0 +1,16 @@
+// Code generated by dcopy-gen. DO NOT EDIT.
+
+package patch
+
+import "github.com/antlabs/dcopy"
+
+// CopyEntityFromPatch 把src拷贝到dst, 结果和dcopy.Copy(dst, src).RegisterTagName("copy").MaxDepth(3).IgnoreZero().Do()一样
+func CopyEntityFromPatch(dst *Entity, src *Patch) error {
+	if dst == nil || src == nil {
+		return &dcopy.Error{Err: dcopy.ErrNotAddressable}
+	}
+	if err := dcopy.Copy(dst, src).RegisterTagName("copy").MaxDepth(3).IgnoreZero().Do(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// Package patch 演示dcopy-gen的-ignore-zero, 补丁模式生成的代码调用dcopy.Copy
+package patch
+
+import "time"
+
+//go:generate go run github.com/antlabs/dcopy/cmd/dcopy-gen -tag copy -depth 3 -ignore-zero -test
+
+//dcopy:gen Entity Patch
+
+type Profile struct {
+	Bio  string `copy:"bio"`
+	Site string `copy:"site"`
+}
+
+type Patch struct {
+	Name    string            `copy:"name"`
+	Age     int               `copy:"age"`
+	Tags    []string          `copy:"tags"`
+	Extra   map[string]string `copy:"extra"`
+	Profile *Profile          `copy:"profile"`
+	Updated time.Time         `copy:"updated"`
+}
+
+type Entity struct {
+	ID      int64             `copy:"id"`
+	Name    string            `copy:"name"`
+	Age     int32             `copy:"age"`
+	Tags    []string          `copy:"tags"`
+	Extra   map[string]string `copy:"extra"`
+	Profile *Profile          `copy:"profile"`
+	Updated time.Time         `copy:"updated"`
+}
@@ -0,0 +1,852 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const directive = "//dcopy:gen"
+
+type config struct {
+	tagName       string
+	maxDepth      int
+	overflow      string
+	convertString bool
+	ignoreZero    bool
+	output        string
+	test          bool
+}
+
+// -overflow的值对应的dcopy.OverflowMode
+var overflowModes = map[string]string{
+	"":         "",
+	"truncate": "",
+	"saturate": "dcopy.OverflowSaturate",
+	"error":    "dcopy.OverflowError",
+}
+
+// 生成的测试文件名
+func (c config) testFile() string {
+	return strings.TrimSuffix(c.output, ".go") + "_test.go"
+}
+
+type pair struct {
+	dst string
+	src string
+}
+
+type pkgInfo struct {
+	name    string
+	fset    *token.FileSet
+	types   map[string]*ast.TypeSpec
+	methods map[string]map[string]bool // 类型名 -> 方法名
+	pairs   []pair
+}
+
+// 读取dir里的包, 不包括测试文件和上次生成的文件
+func loadPackage(dir string, cfg config) (*pkgInfo, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		name := fi.Name()
+		return !strings.HasSuffix(name, "_test.go") && name != cfg.output
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("dcopy-gen: %s: expected one package, got %d", dir, len(pkgs))
+	}
+
+	info := &pkgInfo{fset: fset, types: make(map[string]*ast.TypeSpec), methods: make(map[string]map[string]bool)}
+	for name, pkg := range pkgs {
+		info.name = name
+
+		// 按文件名排序, 保证每次生成的顺序一样
+		fileNames := make([]string, 0, len(pkg.Files))
+		for fileName := range pkg.Files {
+			fileNames = append(fileNames, fileName)
+		}
+		sort.Strings(fileNames)
+
+		for _, fileName := range fileNames {
+			if err := info.addFile(pkg.Files[fileName]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, p := range info.pairs {
+		for _, name := range []string{p.dst, p.src} {
+			if _, ok := info.types[name]; !ok {
+				return nil, fmt.Errorf("dcopy-gen: type %s not found in package %s", name, info.name)
+			}
+		}
+	}
+	return info, nil
+}
+
+func (p *pkgInfo) addFile(file *ast.File) error {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					p.types[ts.Name.Name] = ts
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				continue
+			}
+
+			recv := d.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+
+			if ident, ok := recv.(*ast.Ident); ok {
+				if p.methods[ident.Name] == nil {
+					p.methods[ident.Name] = make(map[string]bool)
+				}
+				p.methods[ident.Name][d.Name.Name] = true
+			}
+		}
+	}
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, directive+" ") {
+				continue
+			}
+
+			args := strings.Fields(strings.TrimPrefix(c.Text, directive))
+			if len(args) != 2 {
+				return fmt.Errorf("dcopy-gen: %s: usage: %s Dst Src", p.fset.Position(c.Pos()), directive)
+			}
+			p.pairs = append(p.pairs, pair{dst: args[0], src: args[1]})
+		}
+	}
+	return nil
+}
+
+// 和reflect.Kind对应, 基础类型直接使用类型名
+type kind string
+
+const (
+	kUnknown   kind = "" // 其他包的类型, 不知道底层类型
+	kStruct    kind = "struct"
+	kPtr       kind = "ptr"
+	kSlice     kind = "slice"
+	kArray     kind = "array"
+	kMap       kind = "map"
+	kInterface kind = "interface"
+	kOther     kind = "other" // chan, func, uintptr等不能拷贝的类型
+)
+
+var basicKinds = map[string]kind{
+	"bool": "bool", "string": "string",
+	"int": "int", "int8": "int8", "int16": "int16", "int32": "int32", "int64": "int64",
+	"uint": "uint", "uint8": "uint8", "uint16": "uint16", "uint32": "uint32", "uint64": "uint64",
+	"byte": "uint8", "rune": "int32",
+	"float32": "float32", "float64": "float64",
+	"complex64": "complex64", "complex128": "complex128",
+	"uintptr": kOther,
+	"error":   kInterface, "any": kInterface,
+}
+
+// 解析之后的类型, expr用于生成代码, under是底层类型
+type typ struct {
+	expr  ast.Expr
+	under ast.Expr
+	name  string // 包里定义的类型名, 匿名类型是空字符串
+	kind  kind
+}
+
+func isNumeric(k kind) bool {
+	switch k {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+func isSigned(k kind) bool {
+	return strings.HasPrefix(string(k), "int")
+}
+
+func isUnsigned(k kind) bool {
+	return strings.HasPrefix(string(k), "uint")
+}
+
+func isFloat(k kind) bool {
+	return strings.HasPrefix(string(k), "float")
+}
+
+func isBasic(k kind) bool {
+	_, ok := basicKinds[string(k)]
+	return ok && k != kOther && k != kInterface
+}
+
+type generator struct {
+	cfg config
+	pkg *pkgInfo
+
+	helpers map[string]string // dst和src的类型 -> 结构体拷贝函数名
+	queue   []helperJob
+	// 正在展开的有名字的slice, map, 指针类型, 再次遇到时是递归的类型, 比如type L []L
+	expanding map[string]bool
+	funcs     bytes.Buffer
+
+	tmp         int
+	floatToUint bool // 使用了dcopyGenFloatToUint
+}
+
+type helperJob struct {
+	name     string
+	dst, src typ
+}
+
+func generate(dir string, cfg config) (map[string][]byte, error) {
+	pkg, err := loadPackage(dir, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkg.pairs) == 0 {
+		return nil, fmt.Errorf("dcopy-gen: no %s directive found in %s", directive, dir)
+	}
+
+	if _, ok := overflowModes[cfg.overflow]; !ok {
+		return nil, fmt.Errorf("dcopy-gen: unknown overflow mode %q, want truncate, saturate or error", cfg.overflow)
+	}
+
+	g := &generator{cfg: cfg, pkg: pkg, helpers: make(map[string]string), expanding: make(map[string]bool)}
+	src, err := g.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{cfg.output: src}
+	if cfg.test {
+		if files[cfg.testFile()], err = g.generateTest(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func (g *generator) typeString(expr ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, g.pkg.fset, expr)
+	return b.String()
+}
+
+func (g *generator) resolve(expr ast.Expr) typ {
+	t := typ{expr: expr, under: expr}
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		r := g.resolve(e.X)
+		r.expr = expr
+		return r
+	case *ast.Ident:
+		if ts, ok := g.pkg.types[e.Name]; ok {
+			r := g.resolve(ts.Type)
+			r.expr = expr
+			// 别名和原来的类型是同一个类型
+			if !ts.Assign.IsValid() {
+				r.name = e.Name
+			}
+			return r
+		}
+
+		if k, ok := basicKinds[e.Name]; ok {
+			t.kind = k
+		}
+	case *ast.StructType:
+		t.kind = kStruct
+	case *ast.StarExpr:
+		t.kind = kPtr
+	case *ast.ArrayType:
+		t.kind = kArray
+		if e.Len == nil {
+			t.kind = kSlice
+		}
+	case *ast.MapType:
+		t.kind = kMap
+	case *ast.InterfaceType:
+		t.kind = kInterface
+	case *ast.ChanType, *ast.FuncType:
+		t.kind = kOther
+	}
+	return t
+}
+
+func (g *generator) elem(t typ) typ {
+	switch e := t.under.(type) {
+	case *ast.StarExpr:
+		return g.resolve(e.X)
+	case *ast.ArrayType:
+		return g.resolve(e.Elt)
+	case *ast.MapType:
+		return g.resolve(e.Value)
+	}
+	panic("dcopy-gen: no elem type")
+}
+
+func (g *generator) identical(a, b typ) bool {
+	return g.typeString(a.expr) == g.typeString(b.expr)
+}
+
+// dst实现了CopyFrom, BeforeCopy, AfterCopy或者DeepCopyInto, 交给dcopy.Copy
+func (g *generator) hasHooks(t typ) bool {
+	m := g.pkg.methods[t.name]
+	return t.name != "" && (m["CopyFrom"] || m["BeforeCopy"] || m["AfterCopy"] || m["DeepCopyInto"])
+}
+
+// 和dcopy里canConvert的规则一样, 用于跳过不兼容的slice和map
+func (g *generator) canConvert(d, s typ) bool {
+	if d.kind == s.kind || d.kind == kInterface || s.kind == kInterface {
+		return true
+	}
+
+	if d.kind == kMap && s.kind == kStruct || d.kind == kStruct && s.kind == kMap || g.mapToStructPtr(d, s) {
+		return true
+	}
+	return isNumeric(d.kind) && isNumeric(s.kind) || g.stringConvert(d, s)
+}
+
+// map拷贝到结构体指针
+func (g *generator) mapToStructPtr(d, s typ) bool {
+	return s.kind == kMap && d.kind == kPtr && g.elem(d).kind == kStruct
+}
+
+// 打开ConvertString时, 字符串和数值, bool之间可以转换
+func (g *generator) stringConvert(d, s typ) bool {
+	if !g.cfg.convertString {
+		return false
+	}
+
+	other := func(k kind) bool { return isNumeric(k) || k == "bool" }
+	return d.kind == "string" && other(s.kind) || s.kind == "string" && other(d.kind)
+}
+
+func (g *generator) newVar(prefix string) string {
+	g.tmp++
+	return prefix + strconv.Itoa(g.tmp)
+}
+
+// &(*p) 写成 p
+func addrOf(v string) string {
+	if strings.HasPrefix(v, "(*") && strings.HasSuffix(v, ")") {
+		return v[2 : len(v)-1]
+	}
+	return "&" + v
+}
+
+// 和dcopy.Copy(&dst, &src)使用相同的配置
+func (g *generator) options(depth string) string {
+	var b strings.Builder
+	if g.cfg.tagName != "" {
+		fmt.Fprintf(&b, ".RegisterTagName(%q)", g.cfg.tagName)
+	}
+
+	if g.cfg.maxDepth >= 0 {
+		if depth == "0" {
+			fmt.Fprintf(&b, ".MaxDepth(%d)", g.cfg.maxDepth)
+		} else {
+			fmt.Fprintf(&b, ".MaxDepth(%d - (%s))", g.cfg.maxDepth, depth)
+		}
+	}
+
+	if mode := overflowModes[g.cfg.overflow]; mode != "" {
+		fmt.Fprintf(&b, ".OverflowMode(%s)", mode)
+	}
+	if g.cfg.convertString {
+		b.WriteString(".ConvertString()")
+	}
+	if g.cfg.ignoreZero {
+		b.WriteString(".IgnoreZero()")
+	}
+	return b.String()
+}
+
+func (g *generator) generate() ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by dcopy-gen. DO NOT EDIT.\n\npackage %s\n\nimport \"github.com/antlabs/dcopy\"\n\n", g.pkg.name)
+
+	for _, p := range g.pkg.pairs {
+		dst := g.resolve(ast.NewIdent(p.dst))
+		src := g.resolve(ast.NewIdent(p.src))
+
+		fmt.Fprintf(&b, "// Copy%sFrom%s 把src拷贝到dst, 结果和dcopy.Copy(dst, src)%s.Do()一样\n", p.dst, p.src, g.options("0"))
+		fmt.Fprintf(&b, "func Copy%sFrom%s(dst *%s, src *%s) error {\n", p.dst, p.src, p.dst, p.src)
+		b.WriteString("if dst == nil || src == nil {\nreturn &dcopy.Error{Err: dcopy.ErrNotAddressable}\n}\n")
+		g.stmt(&b, dst, src, "(*dst)", "(*src)", "0")
+		b.WriteString("return nil\n}\n\n")
+	}
+
+	for len(g.queue) > 0 {
+		job := g.queue[0]
+		g.queue = g.queue[1:]
+		if job.dst.kind == kStruct {
+			g.structFunc(job)
+		} else {
+			g.compositeFunc(job)
+		}
+	}
+	b.Write(g.funcs.Bytes())
+
+	if g.floatToUint {
+		b.WriteString(`// 和dcopy一样, 负数先转成int64
+func dcopyGenFloatToUint(f float64) uint64 {
+	if f < 0 {
+		return uint64(int64(f))
+	}
+	return uint64(f)
+}
+`)
+	}
+
+	return formatSource(b.Bytes())
+}
+
+func formatSource(src []byte) ([]byte, error) {
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("dcopy-gen: format generated code: %v\n%s", err, src)
+	}
+	return out, nil
+}
+
+// 生成把sv拷贝到dv的代码, 和dcopy.dCopy的判断顺序一样
+// depth是dv所在的层次, 只有结构体的字段会加1
+func (g *generator) stmt(w *bytes.Buffer, d, s typ, dv, sv, depth string) {
+	// 补丁模式下每一层都要判断零值, 整个交给dcopy.Copy
+	if g.cfg.ignoreZero || g.hasHooks(d) || d.kind == kUnknown || s.kind == kUnknown || d.kind == kInterface || s.kind == kInterface {
+		g.fallback(w, dv, sv, depth)
+		return
+	}
+
+	if d.kind == s.kind && (d.name != "" || s.name != "") && (d.kind == kPtr || d.kind == kSlice || d.kind == kArray || d.kind == kMap) {
+		key := g.pairKey(d, s)
+		if g.expanding[key] {
+			fmt.Fprintf(w, "if err := %s(%s, %s, %s); err != nil {\nreturn err\n}\n", g.helper(d, s), addrOf(dv), addrOf(sv), depth)
+			return
+		}
+
+		g.expanding[key] = true
+		defer delete(g.expanding, key)
+	}
+
+	switch {
+	case isBasic(d.kind) && isBasic(s.kind):
+		g.basic(w, d, s, dv, sv, depth)
+	case s.kind == kStruct && d.kind == kStruct:
+		fmt.Fprintf(w, "if err := %s(%s, %s, %s); err != nil {\nreturn err\n}\n", g.helper(d, s), addrOf(dv), addrOf(sv), depth)
+	case s.kind == kStruct && d.kind == kMap || s.kind == kMap && d.kind == kStruct || g.mapToStructPtr(d, s):
+		g.fallback(w, dv, sv, depth)
+	case s.kind == kPtr && d.kind == kPtr:
+		g.ptr(w, d, s, dv, sv, depth)
+	case (s.kind == kSlice || s.kind == kArray) && (d.kind == kSlice || d.kind == kArray):
+		g.slice(w, d, s, dv, sv, depth)
+	case s.kind == kMap && d.kind == kMap:
+		g.mapStmt(w, d, s, dv, sv, depth)
+	}
+	// 其他情况类型不兼容, 不拷贝
+}
+
+func (g *generator) fallback(w *bytes.Buffer, dv, sv, depth string) {
+	fmt.Fprintf(w, "if err := dcopy.Copy(%s, %s)%s.Do(); err != nil {\nreturn err\n}\n", addrOf(dv), addrOf(sv), g.options(depth))
+}
+
+// 数值类型之间的转换和dcopy的OverflowTruncate一样, 其他的溢出处理和字符串转换交给dcopy.Copy
+func (g *generator) basic(w *bytes.Buffer, d, s typ, dv, sv, depth string) {
+	dt := g.typeString(d.expr)
+	switch {
+	case g.identical(d, s):
+		fmt.Fprintf(w, "%s = %s\n", dv, sv)
+	case d.kind == s.kind:
+		fmt.Fprintf(w, "%s = %s(%s)\n", dv, dt, sv)
+	case g.stringConvert(d, s):
+		g.fallback(w, dv, sv, depth)
+	case !isNumeric(d.kind) || !isNumeric(s.kind):
+		// 字符串和数值之间需要ConvertString, 不拷贝
+	case overflowModes[g.cfg.overflow] != "":
+		g.fallback(w, dv, sv, depth)
+	case isFloat(s.kind) && isSigned(d.kind):
+		fmt.Fprintf(w, "%s = %s(int64(%s))\n", dv, dt, sv)
+	case isFloat(s.kind) && isUnsigned(d.kind):
+		g.floatToUint = true
+		fmt.Fprintf(w, "%s = %s(dcopyGenFloatToUint(float64(%s)))\n", dv, dt, sv)
+	case d.kind == "float32" && !isFloat(s.kind):
+		// dcopy先把整数转成float64
+		fmt.Fprintf(w, "%s = %s(float64(%s))\n", dv, dt, sv)
+	default:
+		fmt.Fprintf(w, "%s = %s(%s)\n", dv, dt, sv)
+	}
+}
+
+// src是空指针时dst也是空指针, 否则分配新的内存
+func (g *generator) ptr(w *bytes.Buffer, d, s typ, dv, sv, depth string) {
+	p := g.newVar("p")
+	de := d.under.(*ast.StarExpr).X
+	fmt.Fprintf(w, "if %s == nil {\n%s = nil\n} else {\n%s := new(%s)\n", sv, dv, p, g.typeString(de))
+	g.stmt(w, g.elem(d), g.elem(s), "(*"+p+")", "(*"+sv+")", depth)
+	fmt.Fprintf(w, "%s = %s\n}\n", dv, p)
+}
+
+// 和StrategyDefault一样, dst是slice时替换成新的slice, dst是数组时拷贝两边都有的部分
+func (g *generator) slice(w *bytes.Buffer, d, s typ, dv, sv, depth string) {
+	de, se := g.elem(d), g.elem(s)
+	if de.kind == kUnknown || se.kind == kUnknown {
+		g.fallback(w, dv, sv, depth)
+		return
+	}
+
+	if !g.canConvert(de, se) {
+		return
+	}
+
+	i := g.newVar("i")
+	if d.kind == kArray {
+		fmt.Fprintf(w, "for %s := 0; %s < len(%s) && %s < len(%s); %s++ {\n", i, i, dv, i, sv, i)
+		g.stmt(w, de, se, dv+"["+i+"]", sv+"["+i+"]", depth)
+		w.WriteString("}\n")
+		return
+	}
+
+	n := g.newVar("s")
+	if s.kind == kSlice {
+		fmt.Fprintf(w, "if %s == nil {\n%s = nil\n} else {\n", sv, dv)
+	} else {
+		w.WriteString("{\n")
+	}
+	fmt.Fprintf(w, "%s := make(%s, len(%s))\n", n, g.typeString(d.expr), sv)
+	fmt.Fprintf(w, "for %s := range %s {\n", i, sv)
+	g.stmt(w, de, se, n+"["+i+"]", sv+"["+i+"]", depth)
+	fmt.Fprintf(w, "}\n%s = %s\n}\n", dv, n)
+}
+
+// 和StrategyDefault一样, 合并到dst已有的map里
+func (g *generator) mapStmt(w *bytes.Buffer, d, s typ, dv, sv, depth string) {
+	dm, sm := d.under.(*ast.MapType), s.under.(*ast.MapType)
+	dk, sk := g.resolve(dm.Key), g.resolve(sm.Key)
+	de, se := g.elem(d), g.elem(s)
+	if dk.kind == kUnknown || sk.kind == kUnknown || de.kind == kUnknown || se.kind == kUnknown {
+		g.fallback(w, dv, sv, depth)
+		return
+	}
+
+	if !g.canConvert(de, se) || !g.canConvert(dk, sk) {
+		return
+	}
+
+	k, v := g.newVar("k"), g.newVar("v")
+	nk, nv := g.newVar("nk"), g.newVar("nv")
+	fmt.Fprintf(w, "if %s != nil {\nif %s == nil {\n%s = make(%s, len(%s))\n}\n", sv, dv, dv, g.typeString(d.expr), sv)
+	fmt.Fprintf(w, "for %s, %s := range %s {\n", k, v, sv)
+	fmt.Fprintf(w, "var %s %s\n", nk, g.typeString(dm.Key))
+	g.stmt(w, dk, sk, nk, k, depth)
+	fmt.Fprintf(w, "var %s %s\n", nv, g.typeString(dm.Value))
+	g.stmt(w, de, se, nv, v, depth)
+	fmt.Fprintf(w, "%s[%s] = %s\n}\n}\n", dv, nk, nv)
+}
+
+func (g *generator) pairKey(d, s typ) string {
+	return g.typeString(d.expr) + "<-" + g.typeString(s.expr)
+}
+
+// 返回拷贝结构体或者递归类型的函数名, 第一次使用时加到队列里
+func (g *generator) helper(d, s typ) string {
+	key := g.pairKey(d, s)
+	if name, ok := g.helpers[key]; ok {
+		return name
+	}
+
+	name := "dcopyGen" + strconv.Itoa(len(g.helpers)+1)
+	if d.name != "" && s.name != "" {
+		name = "dcopyGen" + d.name + "From" + s.name
+	}
+	g.helpers[key] = name
+	g.queue = append(g.queue, helperJob{name: name, dst: d, src: s})
+	return name
+}
+
+type field struct {
+	name       string
+	typ        ast.Expr
+	embedded   bool
+	unexported bool
+	tagged     bool
+	skip       bool
+	key        string
+	strategy   bool // tag里设置了合并策略, 比如`copy:",append"`
+}
+
+// 和dcopy里strategyTags的选项一样
+var strategyOpts = map[string]bool{"replace": true, "merge": true, "append": true, "keep": true, "overlay": true}
+
+func hasStrategy(v string) bool {
+	opts := strings.Split(v, ",")[1:]
+	for _, opt := range opts {
+		if strategyOpts[strings.TrimSpace(opt)] {
+			return true
+		}
+	}
+	return false
+}
+
+// 和dcopy的newStructFields一样解析字段
+func (g *generator) fields(t typ) ([]field, map[string]int) {
+	var fields []field
+	index := make(map[string]int)
+	for _, f := range t.under.(*ast.StructType).Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s)
+		}
+
+		names := make([]string, 0, len(f.Names))
+		embedded := len(f.Names) == 0
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if embedded {
+			names = append(names, embeddedName(f.Type))
+		}
+
+		for _, name := range names {
+			fi := field{name: name, typ: f.Type, key: name, embedded: embedded}
+			fi.unexported = !ast.IsExported(name) && !embedded
+			fi.skip = fi.unexported
+
+			if g.cfg.tagName == "" {
+				// 没有设置tag时, dcopy只从copy tag里读取合并策略
+				fi.strategy = hasStrategy(tag.Get("copy"))
+			} else {
+				if v, ok := tag.Lookup(g.cfg.tagName); ok && v != "" {
+					fi.tagged = true
+					fi.strategy = hasStrategy(v)
+					if i := strings.IndexByte(v, ','); i != -1 {
+						v = v[:i]
+					}
+
+					if v == "-" {
+						fi.skip = true
+					} else if v != "" {
+						fi.key = v
+					}
+				}
+			}
+
+			fields = append(fields, fi)
+			if _, ok := index[fi.key]; !ok && !fi.skip {
+				index[fi.key] = len(fields) - 1
+			}
+		}
+	}
+	return fields, index
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// 和dcopy的cpyStruct一样, 按照src字段的顺序拷贝
+func (g *generator) structFunc(job helperJob) {
+	w := &g.funcs
+	fmt.Fprintf(w, "func %s(dst *%s, src *%s, depth int) error {\n", job.name, g.typeString(job.dst.expr), g.typeString(job.src.expr))
+
+	srcFields, _ := g.fields(job.src)
+	dstFields, dstIndex := g.fields(job.dst)
+
+	// dst里嵌入结构体的字段会和src的字段配对, 以及字段上设置的合并策略, 交给dcopy.Copy
+	if g.needsRuntime(srcFields, dstFields) {
+		g.fallback(w, "(*dst)", "(*src)", "depth")
+		w.WriteString("return nil\n}\n\n")
+		return
+	}
+
+	// 小写字段不受MaxDepth限制, 类型相同时整个字段浅拷贝
+	if g.identical(job.dst, job.src) {
+		for _, sf := range srcFields {
+			if sf.unexported {
+				fmt.Fprintf(w, "dst.%s = src.%s\n", sf.name, sf.name)
+			}
+		}
+	}
+
+	if g.cfg.maxDepth >= 0 {
+		fmt.Fprintf(w, "if depth+1 > %d {\nreturn nil\n}\n", g.cfg.maxDepth)
+	}
+
+	for _, sf := range srcFields {
+		if sf.skip || g.cfg.tagName != "" && !sf.tagged {
+			continue
+		}
+
+		i, ok := dstIndex[sf.key]
+		if !ok {
+			continue
+		}
+
+		df := dstFields[i]
+		g.stmt(w, g.resolve(df.typ), g.resolve(sf.typ), "dst."+df.name, "src."+sf.name, "depth+1")
+	}
+	w.WriteString("return nil\n}\n\n")
+}
+
+// 递归的slice, map, 指针类型, 生成函数调用自己
+// 只有结构体的字段增加层次, 这里直接使用depth
+func (g *generator) compositeFunc(job helperJob) {
+	w := &g.funcs
+	fmt.Fprintf(w, "func %s(dst *%s, src *%s, depth int) error {\n", job.name, g.typeString(job.dst.expr), g.typeString(job.src.expr))
+	g.stmt(w, job.dst, job.src, "(*dst)", "(*src)", "depth")
+	w.WriteString("return nil\n}\n\n")
+}
+
+func (g *generator) needsRuntime(srcFields, dstFields []field) bool {
+	for _, f := range dstFields {
+		if _, ptr := f.typ.(*ast.StarExpr); f.embedded && !ptr && !f.skip {
+			if k := g.resolve(f.typ).kind; k == kStruct || k == kUnknown {
+				return true
+			}
+		}
+	}
+
+	for _, fields := range [][]field{srcFields, dstFields} {
+		for _, f := range fields {
+			if f.strategy && !f.skip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 生成的测试用不同的数据填充src, 比较生成的函数和dcopy.Copy的结果
+func (g *generator) generateTest() ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `// Code generated by dcopy-gen. DO NOT EDIT.
+
+package %s
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/antlabs/dcopy"
+)
+`, g.pkg.name)
+
+	for _, p := range g.pkg.pairs {
+		fmt.Fprintf(&b, `
+func TestCopy%[1]sFrom%[2]s(t *testing.T) {
+	for seed := 0; seed < 5; seed++ {
+		var src %[2]s
+		dcopyGenFill(reflect.ValueOf(&src).Elem(), seed, 0)
+
+		// dst里已经有数据, 检查合并和补丁模式
+		var got, want %[1]s
+		dcopyGenFill(reflect.ValueOf(&got).Elem(), seed+1, 0)
+		dcopyGenFill(reflect.ValueOf(&want).Elem(), seed+1, 0)
+
+		errGot := Copy%[1]sFrom%[2]s(&got, &src)
+		errWant := dcopy.Copy(&want, &src)%[3]s.Do()
+		if (errGot == nil) != (errWant == nil) {
+			t.Fatalf("seed %%d: generated error %%v, dcopy.Copy error %%v", seed, errGot, errWant)
+		}
+
+		if errGot == nil && !reflect.DeepEqual(got, want) {
+			t.Errorf("seed %%d: generated %%+v, dcopy.Copy %%+v", seed, got, want)
+		}
+	}
+}
+`, p.dst, p.src, g.options("0"))
+	}
+
+	b.WriteString(`
+// 按照seed填充v, level限制递归的层次
+func dcopyGenFill(v reflect.Value, seed, level int) {
+	if level > 4 {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(seed%2 == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(seed*100 + level))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(seed*100 + level))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(seed*100+level) + 0.5)
+	case reflect.String:
+		// 一半是数字, 用于检查ConvertString
+		if seed%2 == 0 {
+			v.SetString(fmt.Sprint(seed*100 + level))
+		} else {
+			v.SetString(fmt.Sprintf("s%d_%d", seed, level))
+		}
+	case reflect.Slice:
+		n := seed % 3
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			dcopyGenFill(s.Index(i), seed+i, level+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			dcopyGenFill(v.Index(i), seed+i, level+1)
+		}
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < seed%3; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			val := reflect.New(v.Type().Elem()).Elem()
+			dcopyGenFill(key, seed+i, level+1)
+			dcopyGenFill(val, seed+i, level+1)
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Ptr:
+		if seed%4 != 0 {
+			p := reflect.New(v.Type().Elem())
+			dcopyGenFill(p.Elem(), seed, level+1)
+			v.Set(p)
+		}
+	case reflect.Interface:
+		if v.NumMethod() == 0 && seed%2 == 1 {
+			v.Set(reflect.ValueOf(fmt.Sprintf("i%d", seed)))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				dcopyGenFill(v.Field(i), seed+i, level+1)
+			}
+		}
+	}
+}
+`)
+	return formatSource(b.Bytes())
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// example里的文件是go generate生成的, 重新生成的结果应该一样
+// 生成的测试(example/dcopy_gen_test.go)检查生成的函数和dcopy.Copy的结果一样
+func Test_Generate_Example(t *testing.T) {
+	for dir, cfg := range map[string]config{
+		"example":         {tagName: "copy", maxDepth: 4, overflow: "truncate"},
+		"example/convert": {maxDepth: -1, overflow: "saturate", convertString: true},
+		"example/patch":   {tagName: "copy", maxDepth: 3, overflow: "truncate", ignoreZero: true},
+	} {
+		cfg.output, cfg.test = "dcopy_gen.go", true
+		files, err := generate(dir, cfg)
+		assert.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		for name, src := range files {
+			want, err := ioutil.ReadFile(filepath.Join(dir, name))
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(src), "%s/%s is out of date, run go generate ./...", dir, name)
+		}
+	}
+}
+
+func Test_Generate_Error(t *testing.T) {
+	write := func(src string) string {
+		dir, err := ioutil.TempDir("", "dcopy-gen")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0644))
+		return dir
+	}
+
+	cfg := config{maxDepth: -1, output: "dcopy_gen.go"}
+
+	dir := write("package a\n\ntype A struct{}\n")
+	defer os.RemoveAll(dir)
+	_, err := generate(dir, cfg)
+	assert.Error(t, err)
+
+	dir2 := write("package a\n\n//dcopy:gen A B\ntype A struct{}\n")
+	defer os.RemoveAll(dir2)
+	_, err = generate(dir2, cfg)
+	assert.EqualError(t, err, "dcopy-gen: type B not found in package a")
+
+	dir3 := write("package a\n\n//dcopy:gen A\ntype A struct{}\n")
+	defer os.RemoveAll(dir3)
+	_, err = generate(dir3, cfg)
+	assert.Error(t, err)
+
+	dir4 := write("package a\n\n//dcopy:gen A A\ntype A struct{}\n")
+	defer os.RemoveAll(dir4)
+	_, err = generate(dir4, config{maxDepth: -1, output: "dcopy_gen.go", overflow: "wrap"})
+	assert.EqualError(t, err, `dcopy-gen: unknown overflow mode "wrap", want truncate, saturate or error`)
+}
+
+// 没有tag和MaxDepth时的生成结果
+func Test_Generate_Default(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcopy-gen")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := `package a
+
+//dcopy:gen A B
+type A struct {
+	ID    int64
+	Name  string ` + "`copy:\"-\"`" + `
+	Score float32
+	N     uint8
+	t     int
+}
+
+type B struct {
+	ID    int
+	Name  string
+	Score int
+	N     float64
+	t     int
+}
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0644))
+
+	files, err := generate(dir, config{maxDepth: -1, output: "dcopy_gen.go"})
+	assert.NoError(t, err)
+
+	out := string(files["dcopy_gen.go"])
+	// 没有设置tag时, 不看tag
+	assert.Contains(t, out, "dst.Name = src.Name\n")
+	assert.Contains(t, out, "dst.ID = int64(src.ID)\n")
+	assert.Contains(t, out, "dst.Score = float32(float64(src.Score))\n")
+	assert.Contains(t, out, "dst.N = uint8(dcopyGenFloatToUint(float64(src.N)))\n")
+	assert.NotContains(t, out, "dst.t")
+	assert.NotContains(t, out, "depth+1 >")
+}
+
+// 递归的map, slice, 指针类型生成调用自己的函数, 不会无限展开
+func Test_Generate_Recursive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcopy-gen")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := `package a
+
+//dcopy:gen A A
+type A struct {
+	T Tree
+	L L
+	P P
+}
+
+type Tree map[string]Tree
+
+type L []L
+
+type P *P
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0644))
+
+	files, err := generate(dir, config{maxDepth: -1, output: "dcopy_gen.go"})
+	assert.NoError(t, err)
+
+	out := string(files["dcopy_gen.go"])
+	assert.Contains(t, out, "func dcopyGenTreeFromTree(dst *Tree, src *Tree, depth int) error {")
+	assert.Contains(t, out, "func dcopyGenLFromL(dst *L, src *L, depth int) error {")
+	assert.Contains(t, out, "func dcopyGenPFromP(dst *P, src *P, depth int) error {")
+}
+
+// dst嵌入了结构体或者字段上有合并策略时, 结构体交给dcopy.Copy
+func Test_Generate_Runtime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcopy-gen")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := `package a
+
+//dcopy:gen A B
+//dcopy:gen C B
+//dcopy:gen D B
+type A struct {
+	Base
+}
+
+type Base struct {
+	ID int
+}
+
+type B struct {
+	ID   int
+	Logs []string
+}
+
+type C struct {
+	Logs []string ` + "`copy:\",append\"`" + `
+}
+
+type D struct {
+	*Base
+	Logs []string
+}
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0644))
+
+	files, err := generate(dir, config{maxDepth: -1, output: "dcopy_gen.go"})
+	assert.NoError(t, err)
+
+	out := string(files["dcopy_gen.go"])
+	assert.Contains(t, out, "func dcopyGenAFromB(dst *A, src *B, depth int) error {\n\tif err := dcopy.Copy(dst, src).Do(); err != nil {")
+	assert.Contains(t, out, "func dcopyGenCFromB(dst *C, src *B, depth int) error {\n\tif err := dcopy.Copy(dst, src).Do(); err != nil {")
+	// 指针的嵌入结构体dcopy不查找里面的字段
+	assert.NotContains(t, out, "func dcopyGenDFromB(dst *D, src *B, depth int) error {\n\tif err := dcopy.Copy(")
+}
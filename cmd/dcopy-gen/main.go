@@ -0,0 +1,52 @@
+// dcopy-gen 读取包里的 //dcopy:gen Dst Src 指令, 生成不使用反射的拷贝函数
+//
+// 在包里加上:
+//
+//	//go:generate go run github.com/antlabs/dcopy/cmd/dcopy-gen -tag copy -test
+//	//dcopy:gen UserDTO User
+//
+// go generate之后生成 func CopyUserDTOFromUser(dst *UserDTO, src *User) error
+// 字段配对, tag, MaxDepth和数值类型转换的规则和dcopy.Copy一样
+// interface, 其他包的类型, 结构体和map互相拷贝, 以及实现了CopyFrom, BeforeCopy等方法的类型, 生成的代码调用dcopy.Copy
+// dst嵌入了结构体, 或者字段上设置了合并策略的结构体, 也调用dcopy.Copy
+// -overflow不是truncate时的数值转换, -convert-string打开的字符串转换, 以及-ignore-zero, 也调用dcopy.Copy
+// 生成的代码不检查循环引用和共享引用, 和NoTrackRef一样
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.tagName, "tag", "", "和RegisterTagName一样, 只拷贝有这个tag的字段")
+	flag.IntVar(&cfg.maxDepth, "depth", -1, "和MaxDepth一样, -1表示不限制")
+	flag.StringVar(&cfg.overflow, "overflow", "truncate", "和OverflowMode一样, 可选的值有truncate, saturate, error")
+	flag.BoolVar(&cfg.convertString, "convert-string", false, "和ConvertString一样, 打开字符串和数值, bool之间的转换")
+	flag.BoolVar(&cfg.ignoreZero, "ignore-zero", false, "和IgnoreZero一样, src里的零值不覆盖dst")
+	flag.StringVar(&cfg.output, "o", "dcopy_gen.go", "生成的文件名")
+	flag.BoolVar(&cfg.test, "test", false, "同时生成测试, 检查生成的函数和dcopy.Copy的结果一样")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	files, err := generate(dir, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), src, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package dcopy
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+type typePair struct {
+	dst reflect.Type
+	src reflect.Type
+}
+
+// 自定义的类型转换函数
+type converter struct {
+	fn     reflect.Value
+	hasErr bool
+}
+
+var (
+	globalConverters  = make(map[typePair]*converter)
+	convertersLock    sync.RWMutex
+	numGlobalConverts int32 // 没有注册全局转换函数时, 跳过加锁查找
+
+	// 每次注册或者删除全局转换函数时加1, 缓存的key里包含这个值
+	globalConvertersVersion uint64
+)
+
+// 检查fn的类型, 必须是func(src S) (D, error)或者func(src S) D
+func newConverter(fn interface{}) (typePair, *converter, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return typePair{}, nil, fmt.Errorf("dcopy: converter must be a func, got %T", fn)
+	}
+
+	t := v.Type()
+	if t.NumIn() != 1 || t.NumOut() < 1 || t.NumOut() > 2 || t.NumOut() == 2 && t.Out(1) != errorType {
+		return typePair{}, nil, fmt.Errorf("dcopy: converter must be func(S) D or func(S) (D, error), got %s", t)
+	}
+
+	return typePair{dst: t.Out(0), src: t.In(0)}, &converter{fn: v, hasErr: t.NumOut() == 2}, nil
+}
+
+// RegisterConverter 注册全局的类型转换函数, fn的类型是func(src S) (D, error)或者func(src S) D
+// 拷贝时遇到src类型是S, dst类型是D的字段(包括slice, map的元素), 直接调用fn, 不再按Kind拷贝
+// 比如 RegisterConverter(func(t time.Time) string { return t.Format(time.RFC3339) })
+func RegisterConverter(fn interface{}) error {
+	pair, c, err := newConverter(fn)
+	if err != nil {
+		return err
+	}
+
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	if _, ok := globalConverters[pair]; !ok {
+		atomic.AddInt32(&numGlobalConverts, 1)
+	}
+	globalConverters[pair] = c
+	atomic.AddUint64(&globalConvertersVersion, 1)
+	return nil
+}
+
+// UnregisterConverter 删除全局的类型转换函数, fn和注册时的函数类型相同即可
+func UnregisterConverter(fn interface{}) {
+	pair, _, err := newConverter(fn)
+	if err != nil {
+		return
+	}
+
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	if _, ok := globalConverters[pair]; ok {
+		atomic.AddInt32(&numGlobalConverts, -1)
+		delete(globalConverters, pair)
+		atomic.AddUint64(&globalConvertersVersion, 1)
+	}
+}
+
+// 先查找这次拷贝设置的转换函数, 再查找全局的
+func (f *dCopy) getConverter(dst, src reflect.Type) *converter {
+	pair := typePair{dst: dst, src: src}
+	if c, ok := f.converters[pair]; ok {
+		return c
+	}
+
+	if atomic.LoadInt32(&numGlobalConverts) == 0 {
+		return nil
+	}
+
+	convertersLock.RLock()
+	c := globalConverters[pair]
+	convertersLock.RUnlock()
+	return c
+}
+
+func (f *dCopy) cpyConverter(a *args, c *converter) error {
+
+	out := c.fn.Call([]reflect.Value{typePtrToValue(a.srcType, a.srcAddr)})
+	if c.hasErr && !out[1].IsNil() {
+		return f.fieldError(a, out[1].Interface().(error))
+	}
+
+	typePtrToValue(a.dstType, a.dstAddr).Set(out[0])
+	f.record(a, ActionConverted)
+	return nil
+}
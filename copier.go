@@ -0,0 +1,219 @@
+package dcopy
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// 拷贝的配置, Copier和dCopy共用
+// map类型的字段在修改时复制一份新的, 多个Copier和dCopy可以共享同一个map
+type options struct {
+	optErr error // 配置出错, 比如Converter的参数不是函数, 拷贝时返回
+
+	tagName string
+	// 零值不限制层次, 这样var c Copier也可以直接使用
+	depthLimited bool
+	maxDepth     int
+	overflow     OverflowMode
+
+	convertString bool
+	converters    map[typePair]*converter
+
+	beforeHooks map[reflect.Type][]HookFunc
+	afterHooks  map[reflect.Type][]HookFunc
+	// 修改converters, beforeHooks, afterHooks时递增, 缓存的key使用它区分不同的函数
+	funcsVersion uint64
+
+	ignoreZero bool
+	strategy   Strategy
+	matcher    FieldMatcher
+
+	continueOnError bool
+	strict          bool
+	noTrackRef      bool
+
+	cache *planCache // nil时不使用缓存
+}
+
+func (o *options) addConverter(fn interface{}) error {
+	pair, c, err := newConverter(fn)
+	if err != nil {
+		return err
+	}
+
+	converters := make(map[typePair]*converter, len(o.converters)+1)
+	for k, v := range o.converters {
+		converters[k] = v
+	}
+	converters[pair] = c
+	o.converters = converters
+	o.touchFuncs()
+	return nil
+}
+
+// maxDepth是noDepthLimited时不限制层次
+func (o *options) setMaxDepth(maxDepth int) {
+	o.maxDepth = maxDepth
+	o.depthLimited = maxDepth != noDepthLimited
+}
+
+// depth超过了MaxDepth
+func (o *options) overDepth(depth int) bool {
+	return o.depthLimited && depth > o.maxDepth
+}
+
+// 所有options共用一个计数器, 不同的函数配置不会得到相同的版本号
+var funcsVersion uint64
+
+func (o *options) touchFuncs() {
+	o.funcsVersion = atomic.AddUint64(&funcsVersion, 1)
+}
+
+func (o *options) addBeforeHook(dst interface{}, fn HookFunc) {
+	o.beforeHooks = addHook(o.beforeHooks, dst, fn)
+	o.touchFuncs()
+}
+
+func (o *options) addAfterHook(dst interface{}, fn HookFunc) {
+	o.afterHooks = addHook(o.afterHooks, dst, fn)
+	o.touchFuncs()
+}
+
+// 复制一份新的map, 不修改原来的
+func addHook(hooks map[reflect.Type][]HookFunc, dst interface{}, fn HookFunc) map[reflect.Type][]HookFunc {
+	newHooks := make(map[reflect.Type][]HookFunc, len(hooks)+1)
+	for k, v := range hooks {
+		newHooks[k] = v
+	}
+
+	typ := hookType(dst)
+	newHooks[typ] = append(newHooks[typ][:len(newHooks[typ]):len(newHooks[typ])], fn)
+	return newHooks
+}
+
+// Copier 可以复用的拷贝配置, 创建之后可以在多个goroutine里同时使用
+// 设置配置的方法不修改原来的Copier, 返回一个新的Copier, 比如
+//
+//	var userCopier = dcopy.NewCopier().RegisterTagName("copy").IgnoreZero()
+//	err := userCopier.Copy(&dst, &src)
+//
+// 零值的Copier和NewCopier()的配置一样, 可以直接作为结构体字段使用
+type Copier struct {
+	opts options
+}
+
+var defaultCopier = NewCopier()
+
+// 创建默认配置的Copier, 和Copy(dst, src)的配置一样
+func NewCopier() *Copier {
+	return &Copier{}
+}
+
+func (c *Copier) with(set func(o *options)) *Copier {
+	n := &Copier{opts: c.opts}
+	set(&n.opts)
+	return n
+}
+
+// 设置最多递归的层次
+func (c *Copier) MaxDepth(maxDepth int) *Copier {
+	return c.with(func(o *options) { o.setMaxDepth(maxDepth) })
+}
+
+// 设置tag name, 规则和dCopy.RegisterTagName一样
+func (c *Copier) RegisterTagName(tagName string) *Copier {
+	return c.with(func(o *options) { o.tagName = tagName })
+}
+
+// 设置数值类型转换溢出时的处理方式
+func (c *Copier) OverflowMode(mode OverflowMode) *Copier {
+	return c.with(func(o *options) { o.overflow = mode })
+}
+
+// 打开字符串和数值, bool之间的转换
+func (c *Copier) ConvertString() *Copier {
+	return c.with(func(o *options) { o.convertString = true })
+}
+
+// 设置类型转换函数, fn的类型是func(src S) (D, error)或者func(src S) D
+// fn的类型不对时, Copy返回错误
+func (c *Copier) Converter(fn interface{}) *Copier {
+	return c.with(func(o *options) {
+		if err := o.addConverter(fn); err != nil && o.optErr == nil {
+			o.optErr = err
+		}
+	})
+}
+
+// 给dst类型注册拷贝之前的回调函数
+func (c *Copier) BeforeCopy(dst interface{}, fn HookFunc) *Copier {
+	return c.with(func(o *options) { o.addBeforeHook(dst, fn) })
+}
+
+// 给dst类型注册拷贝之后的回调函数
+func (c *Copier) AfterCopy(dst interface{}, fn HookFunc) *Copier {
+	return c.with(func(o *options) { o.addAfterHook(dst, fn) })
+}
+
+// 补丁模式, src里的零值不会覆盖dst
+func (c *Copier) IgnoreZero() *Copier {
+	return c.with(func(o *options) { o.ignoreZero = true })
+}
+
+// 设置拷贝map和slice时的合并策略
+func (c *Copier) Strategy(s Strategy) *Copier {
+	return c.with(func(o *options) { o.strategy = s })
+}
+
+// 设置字段的配对方式
+func (c *Copier) FieldMatcher(matcher FieldMatcher) *Copier {
+	return c.with(func(o *options) { o.matcher = matcher })
+}
+
+// 严格模式, 有字段没有拷贝时返回*StrictError
+func (c *Copier) Strict() *Copier {
+	return c.with(func(o *options) { o.strict = true })
+}
+
+// 出错时继续拷贝其他字段, 最后返回MultiError
+func (c *Copier) ContinueOnError() *Copier {
+	return c.with(func(o *options) { o.continueOnError = true })
+}
+
+// 关闭循环引用和共享引用的检查
+func (c *Copier) NoTrackRef() *Copier {
+	return c.with(func(o *options) { o.noTrackRef = true })
+}
+
+// 打开缓存, 缓存属于这个Copier和从它派生的Copier, 缓存的key包含类型和配置, 不同的配置不会互相影响
+// 最多缓存1024个计划, 可以使用CacheSize修改
+func (c *Copier) Cache() *Copier {
+	return c.CacheSize(defaultCacheSize)
+}
+
+// 打开缓存, 最多缓存size个计划, 超过时淘汰最近没有使用的计划, 0表示不限制
+func (c *Copier) CacheSize(size int) *Copier {
+	return c.with(func(o *options) { o.cache = newPlanCache(size) })
+}
+
+// 返回缓存的统计信息, 没有打开缓存时返回零值
+func (c *Copier) CacheStats() PlanCacheStats {
+	if c.opts.cache == nil {
+		return PlanCacheStats{}
+	}
+	return c.opts.cache.stats()
+}
+
+func (c *Copier) newCopy(dst, src interface{}) *dCopy {
+	return newCopy(&c.opts, dst, src)
+}
+
+// 使用c的配置把src拷贝到dst, dst和src必须是指针
+func (c *Copier) Copy(dst, src interface{}) error {
+	return c.newCopy(dst, src).Do()
+}
+
+// 和Copy一样, 同时返回拷贝报告
+func (c *Copier) CopyWithReport(dst, src interface{}) (*Report, error) {
+	return c.newCopy(dst, src).DoWithReport()
+}
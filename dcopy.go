@@ -1,8 +1,6 @@
 package dcopy
 
 import (
-	"errors"
-	"fmt"
 	"reflect"
 	"unsafe"
 )
@@ -12,61 +10,187 @@ const (
 	noTagLimit     = ""
 )
 
+// 数值类型转换时, 超出dst类型范围的处理方式
+type OverflowMode int
+
+const (
+	// 和go的类型转换一样, 直接截断
+	OverflowTruncate OverflowMode = iota
+	// 取dst类型能表示的最大值或者最小值
+	OverflowSaturate
+	// 返回错误, 错误信息里包含字段路径
+	OverflowError
+)
+
 type emptyInterface struct {
 	typ  *struct{}
 	word unsafe.Pointer
 }
 
 type dCopy struct {
-	dstValue reflect.Value
-	srcValue reflect.Value
-	err      error
+	options
+
+	dstType reflect.Type
+	srcType reflect.Type
+	dstAddr unsafe.Pointer
+	srcAddr unsafe.Pointer
+	err     error
+
+	fields map[fieldsKey]*structFields
 
-	tagName  string
-	maxDepth int
+	errs      MultiError
+	strictErr *StrictError
+	report    *Report
 
-	af *allFieldFunc
+	visited map[refKey]refVal
+
+	optsKey optionsKey // 使用缓存时, 这次拷贝的配置指纹
+	// 这次拷贝设置了转换函数或者回调, 函数每次都是新的, 不使用缓存
+	callFuncs bool
 }
 
+// 使用默认的配置拷贝, 可以继续调用MaxDepth, RegisterTagName等方法修改这次拷贝的配置, 最后调用Do()
+// 需要复用配置时使用Copier
 func Copy(dst, src interface{}) *dCopy {
+	return defaultCopier.newCopy(dst, src)
+}
+
+func newCopy(opts *options, dst, src interface{}) *dCopy {
+	if opts.optErr != nil {
+		return &dCopy{err: opts.optErr}
+	}
+
 	if dst == nil || src == nil {
-		return &dCopy{err: errors.New("Unsupported type:nil")}
+		return &dCopy{err: &Error{DstType: reflect.TypeOf(dst), SrcType: reflect.TypeOf(src), Err: ErrNil}}
 	}
 
 	dstValue := reflect.ValueOf(dst)
 	srcValue := reflect.ValueOf(src)
 
 	if dstValue.Kind() != reflect.Ptr || srcValue.Kind() != reflect.Ptr {
-		return &dCopy{err: errors.New("Unsupported type: Not a pointer")}
-	}
-
-	if !dstValue.Elem().CanAddr() {
-		return &dCopy{err: fmt.Errorf("dst:%T value cannot take address", dstValue.Type())}
+		return &dCopy{err: &Error{DstType: dstValue.Type(), SrcType: srcValue.Type(), Err: ErrNotPointer}}
 	}
 
-	if !srcValue.Elem().CanAddr() {
-		return &dCopy{err: fmt.Errorf("src:%T value cannot take address", dstValue.Type())}
+	if !dstValue.Elem().CanAddr() || !srcValue.Elem().CanAddr() {
+		return &dCopy{err: &Error{DstType: dstValue.Type(), SrcType: srcValue.Type(), Err: ErrNotAddressable}}
 	}
 
 	return &dCopy{
-		maxDepth: noDepthLimited,
-		dstValue: dstValue,
-		srcValue: srcValue,
+		options: *opts,
+		dstType: dstValue.Elem().Type(),
+		srcType: srcValue.Elem().Type(),
+		dstAddr: unsafe.Pointer(dstValue.Elem().UnsafeAddr()),
+		srcAddr: unsafe.Pointer(srcValue.Elem().UnsafeAddr()),
 	}
 }
 
 // 设置最多递归的层次
 func (f *dCopy) MaxDepth(maxDepth int) *dCopy {
-	f.maxDepth = maxDepth
+	f.setMaxDepth(maxDepth)
 	return f
 }
 
 // 设置tag name，结构体的tag等于RegisterTagName注册的tag，才会copy值
+// tag的值用于配对dst和src的字段, 比如两边都是`copy:"user_id"`, 字段名不同也会拷贝
+// tag的值是"-"时, 不拷贝这个字段
 func (f *dCopy) RegisterTagName(tagName string) *dCopy {
 	f.tagName = tagName
 	return f
 }
 
+// 设置数值类型转换溢出时的处理方式, 默认是OverflowTruncate
+func (f *dCopy) OverflowMode(mode OverflowMode) *dCopy {
+	f.overflow = mode
+	return f
+}
+
+// 补丁模式, src里的零值不会覆盖dst
+// 数值是0, 空字符串, nil的指针/slice/map/interface都不拷贝, 嵌套的结构体逐个字段判断
+// time.Time这种只有小写字段的结构体整体判断, 零值的小写字段也不拷贝
+// 适合把只填了部分字段的PATCH请求更新到已有的对象上
+func (f *dCopy) IgnoreZero() *dCopy {
+	f.ignoreZero = true
+	return f
+}
+
+// 设置拷贝map和slice时的合并策略, 默认是StrategyDefault
+// 也可以在字段的tag里单独设置, 比如`copy:",append"`, 可选的值有replace, merge, append, keep, overlay
+// 调用了RegisterTagName时从这个tag里读取, 否则从copy tag里读取; tag的名字部分为空时不改变字段的配对
+func (f *dCopy) Strategy(s Strategy) *dCopy {
+	f.strategy = s
+	return f
+}
+
+// 设置字段的配对方式, 默认是MatchExact
+// 内置MatchExact, MatchCaseInsensitive, MatchNormalized, 也可以使用FieldMatcherFunc自定义
+func (f *dCopy) FieldMatcher(matcher FieldMatcher) *dCopy {
+	f.matcher = matcher
+	return f
+}
+
+// 打开字符串和数值, bool之间的转换
+// 比如配置文件里读出来的都是字符串, 可以直接拷贝到int, float64, bool类型的字段
+// 解析失败时Do()返回的错误里包含字段路径
+func (f *dCopy) ConvertString() *dCopy {
+	f.convertString = true
+	return f
+}
+
+// 设置这次拷贝使用的类型转换函数, fn的类型是func(src S) (D, error)或者func(src S) D
+// 优先级高于RegisterConverter注册的全局转换函数
+func (f *dCopy) Converter(fn interface{}) *dCopy {
+	if err := f.addConverter(fn); err != nil {
+		f.err = err
+	}
+	f.callFuncs = true
+	return f
+}
+
+// 给dst类型注册拷贝之前的回调函数, 用于不能实现BeforeCopier的类型
+// dst是结构体或者结构体指针, 只用来确定类型, 比如BeforeCopy((*User)(nil), fn)
+func (f *dCopy) BeforeCopy(dst interface{}, fn HookFunc) *dCopy {
+	f.addBeforeHook(dst, fn)
+	f.callFuncs = true
+	return f
+}
+
+// 给dst类型注册拷贝之后的回调函数, 用于不能实现AfterCopier的类型
+func (f *dCopy) AfterCopy(dst interface{}, fn HookFunc) *dCopy {
+	f.addAfterHook(dst, fn)
+	f.callFuncs = true
+	return f
+}
+
+// 严格模式, 有字段没有拷贝时Do()返回*StrictError
+// 错误里列出所有没有配对的dst字段, src字段和类型不兼容的字段, 以及它们的完整路径
+func (f *dCopy) Strict() *dCopy {
+	f.strict = true
+	return f
+}
+
+// 出错时不终止拷贝, 继续拷贝其他字段, Do()最后返回MultiError, 包含所有出错的字段
+func (f *dCopy) ContinueOnError() *dCopy {
+	f.continueOnError = true
+	return f
+}
+
+// 打开缓存, 第一次拷贝时记录每个字段的拷贝函数, 之后相同的类型和配置直接使用记录的函数
+// 使用包内共享的缓存, Copier可以使用Copier.Cache()创建自己的缓存
+// 这次拷贝设置了Converter, BeforeCopy, AfterCopy时不使用缓存, 需要缓存时在Copier上设置
+func (f *dCopy) Cache() *dCopy {
+	if f.cache == nil {
+		f.cache = sharedCache
+	}
+	return f
+}
+
+// 关闭循环引用和共享引用的检查，可以提升一些性能
+// 确定src里没有环并且不关心共享引用时使用
+func (f *dCopy) NoTrackRef() *dCopy {
+	f.noTrackRef = true
+	return f
+}
+
 // 需要的tag name
 func haveTagName(curTabName string) bool {
 	return len(curTabName) > 0
@@ -77,25 +201,35 @@ func (f *dCopy) Do() error {
 		return f.err
 	}
 
-	arg := argsPool.Get().(*args)
+	f.visited = nil
+	f.strictErr = nil
+	f.errs = nil
+
+	arg := newArgs(nil, f.dstType, f.srcType, f.dstAddr, f.srcAddr)
 	defer argsPool.Put(arg)
 
-	arg.dstType = f.dstValue.Elem().Type()
-	arg.srcType = f.srcValue.Elem().Type()
-	arg.dstAddr = unsafe.Pointer(f.dstValue.Elem().UnsafeAddr())
-	arg.srcAddr = unsafe.Pointer(f.srcValue.Elem().UnsafeAddr())
+	var err error
+	if f.usePlan() {
+		err = f.runPlan(f.getPlan(arg.dstType, arg.srcType, 0), arg)
+	} else {
+		err = f.dCopy(arg, 0)
+	}
 
-	if OpenCache {
-		if ok := getSetFromCacheAndRun(arg); ok {
-			return nil
-		}
+	if err != nil {
+		return err
+	}
 
-		defer func() {
-			saveToCache(arg, f.af)
-		}()
+	if f.strictErr != nil && !f.strictErr.empty() {
+		if len(f.errs) > 0 {
+			return append(f.errs, f.strictErr)
+		}
+		return f.strictErr
 	}
 
-	return f.dCopy(arg, 0)
+	if len(f.errs) > 0 {
+		return f.errs
+	}
+	return nil
 }
 
 func (f *dCopy) cpyDefault(a *args, depth int) error {
@@ -105,120 +239,177 @@ func (f *dCopy) cpyDefault(a *args, depth int) error {
 	dstAddr := a.dstAddr
 	srcAddr := a.srcAddr
 	if dst.Kind() != src.Kind() {
-		return nil
+		return f.cpyConvert(a)
 	}
 
 	set := getSetFunc(src.Kind())
 	if set == nil {
-		return nil
+		return f.mismatch(a)
 	}
 
-	//fmt.Printf("%t:dst:%v:%v:%p:%s:%v\n", OpenCache, dst, src, a.offsetAndFunc, a.srcName, f.srcValue.Type())
 	set(dstAddr, srcAddr)
-	if OpenCache {
-		if f.af == nil {
-			f.af = newAllFieldFunc()
-		}
+	f.record(a, ActionCopied)
+	return nil
+}
+
+// 不同Kind之间的转换, 比如int32 -> int64
+func (f *dCopy) cpyConvert(a *args) error {
+	convert := f.getConvertFunc(a.dstType.Kind(), a.srcType.Kind())
+	if convert == nil {
+		return f.mismatch(a)
+	}
 
-		a.set = set
-		f.af.append(a)
+	if err := convert(a.dstAddr, a.srcAddr, f.overflow); err != nil {
+		return f.fieldError(a, err)
 	}
+	f.record(a, ActionConverted)
 	return nil
 }
 
-func (f *dCopy) cpyPtr(a *args, depth int) error {
+func (f *dCopy) getConvertFunc(dst, src reflect.Kind) convertFunc {
+	if convert := getConvertFunc(dst, src); convert != nil {
+		return convert
+	}
+
+	if f.convertString {
+		return getStringConvertFunc(dst, src)
+	}
+	return nil
+}
+
+// 判断src能否拷贝到dst, 用于提前跳过不兼容的map和slice
+func (f *dCopy) canConvert(dst, src reflect.Type) bool {
+	dk, sk := dst.Kind(), src.Kind()
+	if dk == sk || dk == reflect.Interface || sk == reflect.Interface {
+		return true
+	}
+
+	// 结构体和map之间可以互相拷贝
+	if dk == reflect.Map && sk == reflect.Struct || dk == reflect.Struct && sk == reflect.Map || mapToStructPtr(dst, src) {
+		return true
+	}
+
+	if f.getConverter(dst, src) != nil {
+		return true
+	}
+
+	return f.getConvertFunc(dk, sk) != nil
+}
+
+// elem是编译好的计划, 为nil时使用反射拷贝
+func (f *dCopy) cpyPtr(a *args, depth int, elem *plan) error {
 	dst := a.dstType
 	src := a.srcType
 
 	if dst.Kind() != src.Kind() {
+		return f.mismatch(a)
+	}
+
+	srcPtr := *(*unsafe.Pointer)(a.srcAddr)
+	// src是空指针，dst也置为空指针
+	if srcPtr == nil {
+		*(*unsafe.Pointer)(a.dstAddr) = nil
+		f.record(a, ActionNilSource)
 		return nil
 	}
 
-	arg := argsPool.Get().(*args)
+	key := newRefKey(a, srcPtr, 0)
+	if ref, ok := f.loadRef(key); ok {
+		*(*unsafe.Pointer)(a.dstAddr) = ref.addr
+		return nil
+	}
+
+	// 补丁模式下, dst已经指向的对象直接在原地更新
+	dstPtr := *(*unsafe.Pointer)(a.dstAddr)
+	if !f.ignoreZero || dstPtr == nil {
+		// 分配新的内存，dst和src不共享指向的数据
+		dstPtr = unsafe.Pointer(reflect.New(dst.Elem()).Pointer())
+	}
+	// 先登记再递归，遇到环时直接指向新分配的对象
+	f.saveRef(key, refVal{addr: dstPtr})
+
+	arg := newArgs(a, dst.Elem(), src.Elem(), dstPtr, srcPtr)
 	defer argsPool.Put(arg)
 
-	arg.dstType = dst.Elem()
-	arg.srcType = src.Elem()
-	arg.dstAddr = a.dstAddr
-	arg.srcAddr = a.srcAddr
+	arg.strategy = a.strategy
 
-	//dst = dst.Elem()
-	//src = src.Elem()
+	if err := f.next(elem, arg, depth); err != nil {
+		return err
+	}
 
-	return f.dCopy(arg, depth)
+	*(*unsafe.Pointer)(a.dstAddr) = dstPtr
+	return nil
+}
 
+// 和reflect.SliceHeader一样的内存布局, Data使用unsafe.Pointer, 保证gc可以看到
+type sliceHeader struct {
+	Data unsafe.Pointer
+	Len  int
+	Cap  int
 }
 
-func getHeader(typ reflect.Type, addr unsafe.Pointer) *reflect.SliceHeader {
-	var header reflect.SliceHeader
+func getHeader(typ reflect.Type, addr unsafe.Pointer) *sliceHeader {
 	if typ.Kind() == reflect.Array {
-		header.Data = uintptr(addr)
-		header.Len = typ.Len()
-		header.Cap = typ.Len()
-		return &header
+		return &sliceHeader{Data: addr, Len: typ.Len(), Cap: typ.Len()}
 	}
 
-	return (*reflect.SliceHeader)(addr)
-
+	return (*sliceHeader)(addr)
 }
 
 // 支持异构copy, slice to slice, array to slice, slice to array
-func (f *dCopy) cpySliceArray(a *args, depth int) error {
+// dst和src的元素类型可以不同, 每个元素会递归拷贝
+// dst是slice时, 按照Strategy处理dst里已有的元素
+func (f *dCopy) cpySliceArray(a *args, depth int, elem *plan) error {
 
 	dst := a.dstType
 	src := a.srcType
 	dstAddr := a.dstAddr
 	srcAddr := a.srcAddr
-	if dst.Kind() == reflect.Array && dst.Len() == 0 || dst.Kind() != reflect.Array && dst.Kind() != reflect.Slice {
+	if dst.Kind() == reflect.Array && dst.Len() == 0 {
 		return nil
 	}
 
+	// 编译计划时已经检查过
+	if elem == nil && (dst.Kind() != reflect.Array && dst.Kind() != reflect.Slice || !f.canConvert(dst.Elem(), src.Elem())) {
+		return f.mismatch(a)
+	}
+
 	srcHeader := getHeader(src, srcAddr)
 	dstHeader := getHeader(dst, dstAddr)
 
-	if srcHeader.Len == 0 {
-		return nil
-	}
-
-	if dstHeader.Cap == 0 {
-		newAddr := reflect.MakeSlice(src, srcHeader.Len, srcHeader.Cap).Pointer()
-		dstHeader.Data = newAddr
-		dstHeader.Len = srcHeader.Len
-		dstHeader.Cap = srcHeader.Cap
+	start := 0
+	if dst.Kind() == reflect.Slice {
+		var done bool
+		if start, done = f.prepareSlice(a, srcHeader, dstHeader); done {
+			return nil
+		}
 	}
 
 	l := srcHeader.Len
-	if dstHeader.Cap < l {
-		l = dstHeader.Cap
-	}
-
-	if OpenCache {
-		arg2 := argsPool.Get().(*args)
-		arg2.offsetAndFunc = a.offsetAndFunc
-		f.af.append(arg2)
+	if dstHeader.Len-start < l {
+		l = dstHeader.Len - start
 	}
 
-	elemType := dst.Elem()
+	dstElem := dst.Elem()
+	srcElem := src.Elem()
 	for i := 0; i < l; i++ {
-		dstElemAddr := unsafe.Pointer(uintptr(dstHeader.Data) + uintptr(i)*elemType.Size())
-		srcElemAddr := unsafe.Pointer(uintptr(srcHeader.Data) + uintptr(i)*elemType.Size())
+		dstElemAddr := add(dstHeader.Data, (start+i)*int(dstElem.Size()))
+		srcElemAddr := add(srcHeader.Data, i*int(srcElem.Size()))
+
+		// 基础类型不会出错, 不需要记录下标
+		if elem != nil && elem.op == opSet {
+			if !f.ignoreZero || !isZero(srcElem, srcElemAddr) {
+				elem.set(dstElemAddr, srcElemAddr)
+			}
+			continue
+		}
 
 		err := func() error {
-			arg := argsPool.Get().(*args)
+			arg := newArgs(a, dstElem, srcElem, dstElemAddr, srcElemAddr)
 			defer argsPool.Put(arg)
 
-			arg.dstType = dst.Elem()
-			arg.srcType = src.Elem()
-			arg.dstAddr = dstElemAddr
-			arg.srcAddr = srcElemAddr
-			if OpenCache {
-				arg.offsetAndFunc = &offsetAndFunc{
-					srcKind:   elemType.Kind(),
-					dstOffset: int(elemType.Size()) * i,
-					srcOffset: int(elemType.Size()) * i,
-				}
-			}
-			return f.dCopy(arg, depth)
+			arg.setIndex(i)
+			return f.next(elem, arg, depth)
 		}()
 
 		if err != nil {
@@ -227,7 +418,6 @@ func (f *dCopy) cpySliceArray(a *args, depth int) error {
 
 	}
 
-	dstHeader.Len = l
 	return nil
 }
 
@@ -239,72 +429,77 @@ func typePtrToValue(typ reflect.Type, addr unsafe.Pointer) reflect.Value {
 	return reflect.ValueOf(i).Elem()
 }
 
-func getPtrFromVal(v *reflect.Value) unsafe.Pointer {
-	ei := (*emptyInterface)(unsafe.Pointer(v))
-	return ei.word
-}
-
-func (f *dCopy) cpyMap(a *args, depth int) error {
+func (f *dCopy) cpyMap(a *args, depth int, keyPlan, elemPlan *plan) error {
 	dst := a.dstType
 	src := a.srcType
 	dstAddr := a.dstAddr
 	srcAddr := a.srcAddr
 
 	if dst.Kind() != reflect.Map || src.Kind() != reflect.Map {
-		return nil
+		return f.mismatch(a)
 	}
 
-	// 检查value是否相同
-	if dst.Elem().Kind() != src.Elem().Kind() {
-		return nil
+	// 检查key和value是否可以拷贝, 编译计划时已经检查过
+	if elemPlan == nil && (!f.canConvert(dst.Elem(), src.Elem()) || !f.canConvert(dst.Key(), src.Key())) {
+		return f.mismatch(a)
 	}
 
-	// 检查key是否相同
-	if dst.Key().Kind() != src.Key().Kind() {
+	strategy := f.getStrategy(a)
+	dstVal := typePtrToValue(dst, dstAddr)
+	srcVal := typePtrToValue(src, srcAddr)
+	if srcVal.IsNil() {
+		if strategy == StrategyReplace {
+			dstVal.Set(reflect.Zero(dst))
+		}
+		f.record(a, ActionNilSource)
 		return nil
 	}
 
-	dstVal := typePtrToValue(dst, dstAddr)
-	srcVal := typePtrToValue(src, srcAddr)
+	// map变量本身就是指针
+	key := newRefKey(a, *(*unsafe.Pointer)(srcAddr), 0)
+	if ref, ok := f.loadRef(key); ok {
+		*(*unsafe.Pointer)(dstAddr) = ref.addr
+		return nil
+	}
 
-	if dstVal.IsNil() {
-		newMap := reflect.MakeMapWithSize(src, srcVal.Len())
+	if dstVal.IsNil() || strategy == StrategyReplace {
+		newMap := reflect.MakeMapWithSize(dst, srcVal.Len())
 		dstVal.Set(newMap)
 	}
+	f.saveRef(key, refVal{addr: *(*unsafe.Pointer)(dstAddr)})
+
+	// map的key和value不能取地址, 先放到可以取地址的临时变量里
+	srcKey := reflect.New(src.Key()).Elem()
+	srcElem := reflect.New(src.Elem()).Elem()
+	// 使用dst的类型构造key和value, 支持map[string]A -> map[string]B
+	newKey := reflect.New(dst.Key()).Elem()
+	newVal := reflect.New(dst.Elem()).Elem()
+	zeroKey := reflect.Zero(dst.Key())
+	zeroVal := reflect.Zero(dst.Elem())
 
 	iter := srcVal.MapRange()
 	for iter.Next() {
-		k := iter.Key()
-		v := iter.Value()
+		srcKey.Set(iter.Key())
+		srcElem.Set(iter.Value())
+		newKey.Set(zeroKey)
+		newVal.Set(zeroVal)
 
-		newKey := reflect.New(k.Type()).Elem()
-		err := func() error {
-			arg := argsPool.Get().(*args)
-			defer argsPool.Put(arg)
-
-			arg.dstType = newKey.Type()
-			arg.srcType = k.Type()
-			arg.dstAddr = getPtrFromVal(&newKey)
-			arg.srcAddr = getPtrFromVal(&k)
-
-			return f.dCopy(arg, depth)
-		}()
+		err := f.cpyElem(a, srcKey, dst.Key(), src.Key(), unsafe.Pointer(newKey.UnsafeAddr()), unsafe.Pointer(srcKey.UnsafeAddr()), depth, keyPlan)
 		if err != nil {
 			return err
 		}
 
-		newVal := reflect.New(v.Type()).Elem()
-
-		err = func() error {
-			arg := argsPool.Get().(*args)
-			defer argsPool.Put(arg)
+		if strategy == StrategyMergeKeep || strategy == StrategyOverlay {
+			if old := dstVal.MapIndex(newKey); old.IsValid() {
+				if strategy == StrategyMergeKeep {
+					continue
+				}
+				// 在dst原来的值上覆盖
+				newVal.Set(old)
+			}
+		}
 
-			arg.dstType = newVal.Type()
-			arg.srcType = v.Type()
-			arg.dstAddr = getPtrFromVal(&newVal)
-			arg.srcAddr = getPtrFromVal(&v)
-			return f.dCopy(arg, depth)
-		}()
+		err = f.cpyElem(a, srcKey, dst.Elem(), src.Elem(), unsafe.Pointer(newVal.UnsafeAddr()), unsafe.Pointer(srcElem.UnsafeAddr()), depth, elemPlan)
 		if err != nil {
 			return err
 		}
@@ -315,6 +510,15 @@ func (f *dCopy) cpyMap(a *args, depth int) error {
 	return nil
 }
 
+// 拷贝map的key和value, 路径里记录对应的key
+func (f *dCopy) cpyElem(a *args, key reflect.Value, dstType, srcType reflect.Type, dstAddr, srcAddr unsafe.Pointer, depth int, p *plan) error {
+	arg := newArgs(a, dstType, srcType, dstAddr, srcAddr)
+	defer argsPool.Put(arg)
+
+	arg.setKey(key)
+	return f.next(p, arg, depth)
+}
+
 func (f *dCopy) cpyStruct(a *args, depth int) error {
 
 	dst := a.dstType
@@ -322,43 +526,62 @@ func (f *dCopy) cpyStruct(a *args, depth int) error {
 	dstAddr := a.dstAddr
 	srcAddr := a.srcAddr
 
-	n := src.NumField()
-	for i := 0; i < n; i++ {
+	if dst.Kind() != reflect.Struct {
+		return f.mismatch(a)
+	}
+
+	if err := f.callBefore(a); err != nil {
+		return err
+	}
+
+	srcFields := f.getStructFields(src)
+	dstFields := f.getStructFields(dst)
+
+	var matched []bool
+	if f.strict {
+		matched = make([]bool, len(dstFields.fields))
+	}
+
+	for i := range srcFields.fields {
 
 		err := func() error {
-			sf := src.Field(i)
-			if sf.PkgPath != "" && !sf.Anonymous {
+			sf := &srcFields.fields[i]
+			if sf.unexported {
+				// 小写字段没法逐个深度拷贝, 类型相同时整个字段浅拷贝, 比如time.Time
+				// 补丁模式下零值的小写字段不覆盖dst, 只有小写字段的结构体已经整体判断过
+				if dst == src && !f.skipUnexported(srcFields.opaque, sf.Type, add(srcAddr, int(sf.Offset))) {
+					f.cpyUnexported(sf, add(dstAddr, int(sf.Offset)), add(srcAddr, int(sf.Offset)))
+				}
 				return nil
 			}
 
-			if len(f.tagName) > 0 && !haveTagName(sf.Tag.Get(f.tagName)) {
+			// tag是"-", 或者设置了tag name, 只拷贝有tag的字段
+			if sf.skip || len(f.tagName) > 0 && !sf.tagged {
+				f.recordField(a, sf, nil, ActionSkippedByTag)
 				return nil
 			}
 
-			dstSf, ok := dst.FieldByName(sf.Name)
+			dstSf, ok := dstFields.lookup(sf.key)
 			if !ok {
+				f.unmatchedSrc(a, segment{kind: segField, name: sf.Name}, sf.Type)
 				return nil
 			}
 
-			srcFieldAddr := unsafe.Pointer(uintptr(srcAddr) + sf.Offset)
-			dstFieldAddr := unsafe.Pointer(uintptr(dstAddr) + dstSf.Offset)
+			if matched != nil {
+				matched[dstSf.top] = true
+			}
 
-			arg := argsPool.Get().(*args)
+			srcFieldAddr := add(srcAddr, int(sf.Offset))
+			dstFieldAddr := add(dstAddr, int(dstSf.Offset))
+
+			arg := newArgs(a, dstSf.Type, sf.Type, dstFieldAddr, srcFieldAddr)
 			defer argsPool.Put(arg)
 
-			arg.dstType = dstSf.Type
-			arg.srcType = sf.Type
-			arg.dstAddr = dstFieldAddr
-			arg.srcAddr = srcFieldAddr
-			if OpenCache {
-				arg.srcName = sf.Name
-				arg.offsetAndFunc = &offsetAndFunc{
-					srcKind:   sf.Type.Kind(),
-					dstOffset: int(dstSf.Offset),
-					srcOffset: int(sf.Offset),
-				}
+			arg.setField(sf.Name)
+			arg.strategy = dstSf.strategy
+			if arg.strategy == StrategyDefault {
+				arg.strategy = sf.strategy
 			}
-
 			return f.dCopy(arg, depth+1)
 		}()
 
@@ -367,69 +590,141 @@ func (f *dCopy) cpyStruct(a *args, depth int) error {
 		}
 	}
 
-	return nil
+	f.unmatchedDst(a, dstFields, matched)
+	return f.callAfter(a)
+}
+
+func (f *dCopy) skipUnexported(opaque bool, typ reflect.Type, srcAddr unsafe.Pointer) bool {
+	return f.ignoreZero && !opaque && typePtrToValue(typ, srcAddr).IsZero()
 }
 
+func (f *dCopy) cpyUnexported(sf *fieldInfo, dstAddr, srcAddr unsafe.Pointer) {
+
+	typePtrToValue(sf.Type, dstAddr).Set(typePtrToValue(sf.Type, srcAddr))
+}
+
+// src是interface, 取出里面的值再拷贝
 func (f *dCopy) cpyInterface(a *args, depth int) error {
 	dst := a.dstType
 	src := a.srcType
 	dstAddr := a.dstAddr
 	srcAddr := a.srcAddr
 
-	if dst.Kind() != src.Kind() {
+	srcVal := typePtrToValue(src, srcAddr).Elem()
+	if !srcVal.IsValid() {
+		// src是nil interface
+		if dst.Kind() == reflect.Interface {
+			typePtrToValue(dst, dstAddr).Set(reflect.Zero(dst))
+		}
+		f.record(a, ActionNilSource)
 		return nil
 	}
 
-	dstInterfaceValue := typePtrToValue(dst, dstAddr)
-	srcInterfaceValue := typePtrToValue(src, srcAddr)
+	// interface里的值不能取地址, 先放到可以取地址的临时变量里
+	tmp := reflect.New(srcVal.Type()).Elem()
+	tmp.Set(srcVal)
 
-	srcVal := srcInterfaceValue.Elem()
+	arg := newArgs(a, dst, srcVal.Type(), dstAddr, unsafe.Pointer(tmp.UnsafeAddr()))
+	defer argsPool.Put(arg)
 
-	newDst := reflect.New(srcVal.Type()).Elem()
+	if dst.Kind() == reflect.Interface {
+		return f.cpyToInterface(arg, depth, nil)
+	}
 
-	if srcVal.CanAddr() {
-		arg := argsPool.Get().(*args)
-		defer argsPool.Put(arg)
+	return f.dCopy(arg, depth)
+}
 
-		arg.dstType = newDst.Type()
-		arg.srcType = srcVal.Type()
-		arg.dstAddr = unsafe.Pointer(newDst.UnsafeAddr())
-		arg.srcAddr = unsafe.Pointer(srcVal.UnsafeAddr())
+// dst是interface, src是具体的类型, 深度拷贝一份src再放到dst里
+func (f *dCopy) cpyToInterface(a *args, depth int, elem *plan) error {
+	dst := a.dstType
+	src := a.srcType
 
-		if err := f.dCopy(arg, depth); err != nil {
-			return err
-		}
+	if elem == nil && !src.Implements(dst) {
+		return f.mismatch(a)
 	}
 
-	newDst.Set(srcVal)
+	newDst := reflect.New(src).Elem()
+
+	arg := newArgs(a, src, src, unsafe.Pointer(newDst.UnsafeAddr()), a.srcAddr)
+	defer argsPool.Put(arg)
+
+	if err := f.next(elem, arg, depth); err != nil {
+		return err
+	}
 
-	dstInterfaceValue.Set(newDst)
+	typePtrToValue(dst, a.dstAddr).Set(newDst)
 	return nil
 }
 
+// 补丁模式下判断src是否是零值, 结构体和数组需要逐个成员判断, 不在这里跳过
+// 只有小写字段的结构体(比如time.Time)没法逐个字段判断, 整体判断
+func isZero(typ reflect.Type, addr unsafe.Pointer) bool {
+	switch typ.Kind() {
+	case reflect.Struct:
+		if !opaqueStruct(typ) {
+			return false
+		}
+	case reflect.Array:
+		return false
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		return *(*unsafe.Pointer)(addr) == nil
+	}
+
+	return typePtrToValue(typ, addr).IsZero()
+}
+
 func (f *dCopy) dCopy(a *args, depth int) error {
 	if f.err != nil {
 		return f.err
 	}
 
-	if f.maxDepth != noDepthLimited && depth > f.maxDepth {
+	if f.overDepth(depth) {
+		f.record(a, ActionSkippedByDepth)
+		return nil
+	}
+
+	if f.ignoreZero && isZero(a.srcType, a.srcAddr) {
+		f.record(a, ActionSkippedZero)
 		return nil
 	}
 
-	switch a.srcType.Kind() {
+	if c := f.getConverter(a.dstType, a.srcType); c != nil {
+		return f.cpyConverter(a, c)
+	}
+
+	if ok, err := f.cpySelf(a); ok {
+		return err
+	}
+
+	srcKind := a.srcType.Kind()
+	if srcKind == reflect.Interface {
+		return f.cpyInterface(a, depth)
+	}
+
+	dstKind := a.dstType.Kind()
+	if dstKind == reflect.Interface {
+		return f.cpyToInterface(a, depth, nil)
+	}
+
+	switch srcKind {
 	case reflect.Slice, reflect.Array:
-		return f.cpySliceArray(a, depth)
+		return f.cpySliceArray(a, depth, nil)
 	case reflect.Map:
-		return f.cpyMap(a, depth)
+		if dstKind == reflect.Struct {
+			return f.cpyMapToStruct(a, depth)
+		}
+		if mapToStructPtr(a.dstType, a.srcType) {
+			return f.cpyMapToStructPtr(a, depth)
+		}
+		return f.cpyMap(a, depth, nil, nil)
 	case reflect.Struct:
+		if dstKind == reflect.Map {
+			return f.cpyStructToMap(a, depth)
+		}
 		return f.cpyStruct(a, depth)
-	case reflect.Interface:
-		return f.cpyInterface(a, depth)
 	case reflect.Ptr:
-		return f.cpyPtr(a, depth)
+		return f.cpyPtr(a, depth, nil)
 	default:
 		return f.cpyDefault(a, depth)
 	}
-
-	return nil
 }
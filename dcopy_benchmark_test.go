@@ -49,14 +49,10 @@ func Benchmark_Use_Ptr_dcopy(b *testing.B) {
 }
 
 func Benchmark_Use_Ptr_dcopy_Cache(b *testing.B) {
-	OpenCache = true
-	defer func() {
-		OpenCache = false
-	}()
-
+	c := NewCopier().Cache()
 	for i := 0; i < b.N; i++ {
 		var dst testData
-		Copy(&dst, &td).Do()
+		c.Copy(&dst, &td)
 	}
 }
 
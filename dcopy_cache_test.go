@@ -1,6 +1,7 @@
 package dcopy
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,12 +44,16 @@ func defaultTestCacheData() (src testCacheData) {
 func Test_Cache(t *testing.T) {
 	var dst testCacheData
 
-	OpenCache = true
-	defer func() { OpenCache = false }()
-
 	src := defaultTestCacheData()
 
-	err := Copy(&dst, &src).Do()
+	c := NewCopier().Cache()
+	err := c.Copy(&dst, &src)
+	assert.NoError(t, err)
+	assert.Equal(t, src, dst)
+
+	// 第二次使用缓存
+	dst = testCacheData{}
+	err = c.Copy(&dst, &src)
 	assert.NoError(t, err)
 	assert.Equal(t, dst.ID, 3)
 	assert.Equal(t, dst.Int8, int8(8))
@@ -63,3 +68,161 @@ func Test_Cache(t *testing.T) {
 	assert.Equal(t, dst.S, "hello world")
 	assert.Equal(t, dst.Array, [4]int{1, 2, 3})
 }
+
+// 缓存的key包含配置, 不同配置的拷贝不会使用同一个缓存
+func Test_Cache_Options(t *testing.T) {
+	type src struct {
+		ID   int `copy:"id"`
+		Name string
+	}
+
+	type dst struct {
+		ID   int `copy:"id"`
+		Name string
+	}
+
+	c := NewCopier().Cache()
+	var d dst
+	assert.NoError(t, c.RegisterTagName("copy").Copy(&d, &src{ID: 1, Name: "a"}))
+	assert.Equal(t, dst{ID: 1}, d)
+
+	d = dst{}
+	assert.NoError(t, c.Copy(&d, &src{ID: 1, Name: "a"}))
+	assert.Equal(t, dst{ID: 1, Name: "a"}, d)
+
+	// dCopy.Cache()使用共享的缓存
+	d = dst{}
+	assert.NoError(t, Copy(&d, &src{ID: 2, Name: "b"}).RegisterTagName("copy").Cache().Do())
+	assert.Equal(t, dst{ID: 2}, d)
+
+	d = dst{}
+	assert.NoError(t, Copy(&d, &src{ID: 2, Name: "b"}).Cache().Do())
+	assert.Equal(t, dst{ID: 2, Name: "b"}, d)
+
+	// IgnoreZero跳过的字段每次不一样
+	d = dst{ID: 9, Name: "x"}
+	assert.NoError(t, c.IgnoreZero().Copy(&d, &src{ID: 3}))
+	assert.Equal(t, dst{ID: 3, Name: "x"}, d)
+	assert.NoError(t, c.IgnoreZero().Copy(&d, &src{Name: "y"}))
+	assert.Equal(t, dst{ID: 3, Name: "y"}, d)
+}
+
+// 多个goroutine同时使用缓存
+func Test_Cache_Concurrent(t *testing.T) {
+	c := NewCopier().Cache()
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 100; j++ {
+				var dst testCacheData
+				src := defaultTestCacheData()
+				src.ID = j
+				assert.NoError(t, c.Copy(&dst, &src))
+				assert.Equal(t, src, dst)
+			}
+		}()
+	}
+
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+}
+
+// 测试缓存的统计信息和淘汰
+func Test_Cache_Stats(t *testing.T) {
+	type a struct{ A int }
+	type b struct{ B int }
+	type c struct{ C int }
+
+	copier := NewCopier().CacheSize(2)
+	assert.Equal(t, PlanCacheStats{Size: 2}, copier.CacheStats())
+
+	var da a
+	assert.NoError(t, copier.Copy(&da, &a{A: 1}))
+	assert.NoError(t, copier.Copy(&da, &a{A: 2}))
+	assert.Equal(t, 2, da.A)
+	assert.Equal(t, PlanCacheStats{Hits: 1, Misses: 1, Builds: 1, Len: 1, Size: 2}, copier.CacheStats())
+
+	var db b
+	var dc c
+	assert.NoError(t, copier.Copy(&db, &b{B: 1}))
+	assert.NoError(t, copier.Copy(&dc, &c{C: 1}))
+	stats := copier.CacheStats()
+	assert.Equal(t, uint64(3), stats.Builds)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Len)
+
+	// 被淘汰的计划重新编译
+	assert.NoError(t, copier.Copy(&da, &a{A: 3}))
+	assert.NoError(t, copier.Copy(&db, &b{B: 3}))
+	assert.NoError(t, copier.Copy(&dc, &c{C: 3}))
+	assert.Equal(t, a{A: 3}, da)
+	assert.Equal(t, b{B: 3}, db)
+	assert.Equal(t, c{C: 3}, dc)
+	assert.Equal(t, 2, copier.CacheStats().Len)
+
+	// 不限制大小
+	copier = NewCopier().CacheSize(0)
+	assert.NoError(t, copier.Copy(&da, &a{}))
+	assert.NoError(t, copier.Copy(&db, &b{}))
+	assert.NoError(t, copier.Copy(&dc, &c{}))
+	assert.Equal(t, PlanCacheStats{Misses: 3, Builds: 3, Len: 3}, copier.CacheStats())
+
+	// 没有打开缓存
+	assert.Equal(t, PlanCacheStats{}, NewCopier().CacheStats())
+}
+
+// 测试修改缓存大小
+func Test_Cache_Resize(t *testing.T) {
+	cache := newPlanCache(0)
+	for i := 0; i < 10; i++ {
+		cache.save(planKey{depth: i}, &plan{})
+	}
+	assert.NotNil(t, cache.get(planKey{depth: 9}))
+
+	cache.resize(3)
+	stats := cache.stats()
+	assert.Equal(t, 3, stats.Len)
+	assert.Equal(t, uint64(7), stats.Evictions)
+	// 最近使用过的计划没有被淘汰
+	assert.NotNil(t, cache.get(planKey{depth: 9}))
+
+	cache.save(planKey{depth: 10}, &plan{})
+	assert.Equal(t, 3, cache.stats().Len)
+	assert.Equal(t, 3, len(cache.ring))
+}
+
+// 每次设置的转换函数和回调不一样, 不能使用之前编译的计划
+func Test_Cache_Funcs(t *testing.T) {
+	type src struct{ N int }
+	type dst struct{ N string }
+
+	c := NewCopier().Cache()
+	for k := 0; k < 5; k++ {
+		k := k
+		conv := func(x int) string { return strconv.Itoa(x + k) }
+
+		var d dst
+		assert.NoError(t, Copy(&d, &src{N: 1}).Converter(conv).Cache().Do())
+		assert.Equal(t, strconv.Itoa(1+k), d.N)
+
+		d = dst{}
+		assert.NoError(t, c.Converter(conv).Copy(&d, &src{N: 1}))
+		assert.Equal(t, strconv.Itoa(1+k), d.N)
+
+		// 新注册的回调会被调用
+		called := 0
+		hook := func(dst, src interface{}) error { called++; return nil }
+		assert.NoError(t, Copy(&d, &src{N: 1}).Converter(conv).AfterCopy(&d, hook).Cache().Do())
+		assert.NoError(t, c.Converter(conv).BeforeCopy(&d, hook).Copy(&d, &src{N: 1}))
+		assert.Equal(t, 2, called)
+	}
+
+	// dCopy上设置的函数不放到缓存里
+	stats := CacheStats()
+	var d dst
+	assert.NoError(t, Copy(&d, &src{N: 1}).Converter(strconv.Itoa).Cache().Do())
+	assert.Equal(t, stats.Builds, CacheStats().Builds)
+	assert.Equal(t, "1", d.N)
+}
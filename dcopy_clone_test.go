@@ -0,0 +1,90 @@
+package dcopy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试Clone
+func Test_Clone(t *testing.T) {
+	type address struct {
+		City string
+	}
+
+	type user struct {
+		Name    string
+		Addr    *address
+		Tags    []string
+		Attrs   map[string]interface{}
+		Any     interface{}
+		private int
+	}
+
+	src := &user{
+		Name:    "a",
+		Addr:    &address{City: "sz"},
+		Tags:    []string{"x"},
+		Attrs:   map[string]interface{}{"n": []int{1}},
+		Any:     &address{City: "bj"},
+		private: 1,
+	}
+
+	v, err := Clone(src)
+	assert.NoError(t, err)
+	dst := v.(*user)
+	assert.Equal(t, src.Name, dst.Name)
+	assert.Equal(t, src.Addr, dst.Addr)
+	assert.Equal(t, src.Tags, dst.Tags)
+	assert.Equal(t, src.Attrs, dst.Attrs)
+	assert.Equal(t, src.Any, dst.Any)
+	assert.Equal(t, src.private, dst.private)
+
+	// 和src不共享内存
+	assert.True(t, dst != src)
+	assert.True(t, dst.Addr != src.Addr)
+	assert.True(t, dst.Any.(*address) != src.Any.(*address))
+	dst.Tags[0] = "y"
+	dst.Attrs["n"].([]int)[0] = 2
+	assert.Equal(t, "x", src.Tags[0])
+	assert.Equal(t, 1, src.Attrs["n"].([]int)[0])
+
+	// 非指针的值
+	v, err = Clone(map[string][]int{"a": {1}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]int{"a": {1}}, v)
+
+	v, err = Clone(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	v, err = Clone(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	// 使用Copier的配置
+	v, err = NewCopier().MaxDepth(1).Clone(*src)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", v.(user).Name)
+}
+
+// 小写字段没法逐个深度拷贝, 整个字段浅拷贝, 和src共享里面的内存
+func Test_Clone_Unexported(t *testing.T) {
+	type pPriv struct {
+		Name string
+		tags []string
+		m    map[string]int
+	}
+
+	src := pPriv{Name: "a", tags: []string{"x"}, m: map[string]int{"a": 1}}
+	v, err := Clone(src)
+	assert.NoError(t, err)
+
+	dst := v.(pPriv)
+	assert.Equal(t, src, dst)
+
+	dst.tags[0] = "y"
+	dst.m["a"] = 2
+	assert.Equal(t, []string{"y"}, src.tags)
+	assert.Equal(t, map[string]int{"a": 2}, src.m)
+}
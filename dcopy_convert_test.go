@@ -0,0 +1,163 @@
+package dcopy
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试不同宽度的数值类型之间的转换
+func Test_Convert_Number(t *testing.T) {
+	type model struct {
+		ID    int32
+		Age   uint8
+		Score float32
+		Rate  float64
+		Count int
+		Items []int16
+		Tags  map[string]int64
+	}
+
+	type api struct {
+		ID    int64
+		Age   int
+		Score float64
+		Rate  int
+		Count uint16
+		Items []int64
+		Tags  map[string]float64
+	}
+
+	src := model{
+		ID:    32,
+		Age:   18,
+		Score: 1.5,
+		Rate:  9.9,
+		Count: 100,
+		Items: []int16{1, 2, 3},
+		Tags:  map[string]int64{"a": 1},
+	}
+
+	var dst api
+	err := Copy(&dst, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, api{
+		ID:    32,
+		Age:   18,
+		Score: 1.5,
+		Rate:  9,
+		Count: 100,
+		Items: []int64{1, 2, 3},
+		Tags:  map[string]float64{"a": 1},
+	}, dst)
+}
+
+// 测试溢出时的三种处理方式
+func Test_Convert_Overflow(t *testing.T) {
+	type src struct {
+		I   int64
+		U   uint64
+		Neg int
+		F   float64
+	}
+
+	type dst struct {
+		I   int8
+		U   int32
+		Neg uint16
+		F   float32
+	}
+
+	s := src{I: 300, U: math.MaxUint64, Neg: -1, F: math.MaxFloat64}
+
+	var d dst
+	err := Copy(&d, &s).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, int8(44), d.I)
+	assert.Equal(t, int32(-1), d.U)
+	assert.Equal(t, uint16(math.MaxUint16), d.Neg)
+	assert.True(t, math.IsInf(float64(d.F), 1))
+
+	d = dst{}
+	err = Copy(&d, &s).OverflowMode(OverflowSaturate).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, dst{I: math.MaxInt8, U: math.MaxInt32, Neg: 0, F: math.MaxFloat32}, d)
+
+	d = dst{}
+	err = Copy(&d, &s).OverflowMode(OverflowError).Do()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrOverflow))
+	assert.Contains(t, err.Error(), "field I")
+
+	// 错误信息里包含完整的字段路径
+	type row struct {
+		Items map[string][]int64
+	}
+	type row2 struct {
+		Items map[string][]int8
+	}
+	r := row{Items: map[string][]int64{"sku": {1, 1000}}}
+	var r2 row2
+	err = Copy(&r2, &r).OverflowMode(OverflowError).Do()
+	assert.True(t, errors.Is(err, ErrOverflow))
+	assert.Contains(t, err.Error(), `field Items["sku"][1]`)
+}
+
+// 测试字符串和数值, bool之间的转换
+func Test_Convert_String(t *testing.T) {
+	type config struct {
+		Port    string
+		Debug   string
+		Rate    string
+		Timeout string
+		Retry   string
+		Empty   string
+		Ports   []string
+	}
+
+	type server struct {
+		Port    int
+		Debug   bool
+		Rate    float64
+		Timeout uint32
+		Retry   int8
+		Empty   int
+		Ports   []uint16
+	}
+
+	src := config{Port: "8080", Debug: "true", Rate: "0.5", Timeout: " 30 ", Retry: "3", Ports: []string{"80", "443"}}
+
+	// 默认不转换
+	var d server
+	err := Copy(&d, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, server{}, d)
+
+	err = Copy(&d, &src).ConvertString().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, server{Port: 8080, Debug: true, Rate: 0.5, Timeout: 30, Retry: 3, Ports: []uint16{80, 443}}, d)
+
+	// 反方向, 数值转成字符串
+	var c config
+	err = Copy(&c, &d).ConvertString().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, config{Port: "8080", Debug: "true", Rate: "0.5", Timeout: "30", Retry: "3", Empty: "0", Ports: []string{"80", "443"}}, c)
+
+	// 解析失败, 错误信息里包含字段路径
+	src = config{Port: "http", Ports: []string{"80"}}
+	err = Copy(&server{}, &src).ConvertString().Do()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field Port")
+
+	src = config{Ports: []string{"80", "x"}}
+	err = Copy(&server{}, &src).ConvertString().Do()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field Ports[1]")
+
+	// 溢出
+	src = config{Retry: "300"}
+	err = Copy(&server{}, &src).ConvertString().OverflowMode(OverflowError).Do()
+	assert.True(t, errors.Is(err, ErrOverflow))
+}
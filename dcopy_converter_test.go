@@ -0,0 +1,71 @@
+package dcopy
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type money struct {
+	Cent int64
+}
+
+// 测试自定义的类型转换函数
+func Test_Converter(t *testing.T) {
+	type order struct {
+		Created time.Time
+		Times   []time.Time
+		Prices  map[string]money
+	}
+
+	type orderDTO struct {
+		Created string
+		Times   []string
+		Prices  map[string]string
+	}
+
+	timeToString := func(t time.Time) string { return t.Format("2006-01-02") }
+	moneyToString := func(m money) (string, error) {
+		return strconv.FormatInt(m.Cent/100, 10) + "." + strconv.FormatInt(m.Cent%100, 10), nil
+	}
+
+	assert.NoError(t, RegisterConverter(timeToString))
+	defer UnregisterConverter(timeToString)
+
+	now := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	src := order{
+		Created: now,
+		Times:   []time.Time{now, now.AddDate(0, 0, 1)},
+		Prices:  map[string]money{"sku": {Cent: 1050}},
+	}
+
+	var d orderDTO
+	err := Copy(&d, &src).Converter(moneyToString).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, orderDTO{
+		Created: "2020-10-01",
+		Times:   []string{"2020-10-01", "2020-10-02"},
+		Prices:  map[string]string{"sku": "10.50"},
+	}, d)
+
+	// 这次拷贝设置的转换函数优先
+	d = orderDTO{}
+	err = Copy(&d, &src).Converter(func(t time.Time) string { return t.Format("2006") }).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "2020", d.Created)
+
+	// 转换函数返回的错误
+	errBad := errors.New("bad money")
+	err = Copy(&d, &src).Converter(func(m money) (string, error) { return "", errBad }).Do()
+	assert.True(t, errors.Is(err, errBad))
+	assert.True(t, strings.Contains(err.Error(), `Prices["sku"]`))
+
+	// 错误的函数类型
+	assert.Error(t, RegisterConverter(1))
+	assert.Error(t, RegisterConverter(func(a, b int) int { return 0 }))
+	assert.Error(t, Copy(&d, &src).Converter(func(int) (int, int) { return 0, 0 }).Do())
+}
@@ -0,0 +1,111 @@
+package dcopy
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试复用Copier的配置
+func Test_Copier(t *testing.T) {
+	type src struct {
+		ID    int    `copy:"id"`
+		Name  string `copy:"name"`
+		Price int64  `copy:"price"`
+	}
+
+	type dst struct {
+		UserID int    `copy:"id"`
+		Name   string `copy:"name"`
+		Price  string `copy:"price"`
+	}
+
+	c := NewCopier().RegisterTagName("copy").Converter(func(p int64) string { return strconv.FormatInt(p, 10) })
+
+	var d dst
+	assert.NoError(t, c.Copy(&d, &src{ID: 1, Name: "a", Price: 100}))
+	assert.Equal(t, dst{UserID: 1, Name: "a", Price: "100"}, d)
+
+	// 派生新的Copier, 不影响原来的
+	patch := c.IgnoreZero()
+	assert.NoError(t, patch.Copy(&d, &src{Name: "b"}))
+	assert.Equal(t, dst{UserID: 1, Name: "b", Price: "100"}, d)
+
+	assert.NoError(t, c.Copy(&d, &src{Name: "c"}))
+	assert.Equal(t, dst{UserID: 0, Name: "c", Price: "0"}, d)
+
+	// 在Copy()返回的dCopy上修改配置, 不影响Copier
+	d = dst{}
+	assert.NoError(t, Copy(&d, &src{ID: 2, Price: 1}).RegisterTagName("copy").Converter(func(p int64) string { return "x" }).Do())
+	assert.Equal(t, dst{UserID: 2, Price: "x"}, d)
+	assert.Nil(t, defaultCopier.opts.converters)
+	assert.NoError(t, c.Copy(&d, &src{Price: 3}))
+	assert.Equal(t, "3", d.Price)
+
+	// Converter的参数不对
+	err := NewCopier().Converter(1).Copy(&d, &src{})
+	assert.Error(t, err)
+}
+
+// 测试多个goroutine同时使用一个Copier
+func Test_Copier_Concurrent(t *testing.T) {
+	type item struct {
+		N int
+		S []string
+	}
+
+	errBad := errors.New("bad")
+	c := NewCopier().AfterCopy((*item)(nil), func(dst, src interface{}) error {
+		if dst.(*item).N < 0 {
+			return errBad
+		}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var d item
+				s := item{N: i*100 + j, S: []string{strconv.Itoa(j)}}
+				assert.NoError(t, c.Copy(&d, &s))
+				assert.Equal(t, s, d)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var d item
+	assert.True(t, errors.Is(c.Copy(&d, &item{N: -1}), errBad))
+}
+
+// 零值的Copier可以直接使用, 不限制层次
+func Test_Copier_Zero(t *testing.T) {
+	type addr struct{ City string }
+	type user struct {
+		Name string
+		Age  int
+		Addr *addr
+	}
+
+	var c Copier
+	var d user
+	assert.NoError(t, c.Copy(&d, &user{Name: "a", Age: 1, Addr: &addr{City: "sz"}}))
+	assert.Equal(t, user{Name: "a", Age: 1, Addr: &addr{City: "sz"}}, d)
+
+	// 作为结构体字段
+	var svc struct{ copier Copier }
+	d = user{}
+	assert.NoError(t, svc.copier.Cache().Copy(&d, &user{Name: "b"}))
+	assert.Equal(t, user{Name: "b"}, d)
+
+	// MaxDepth(0)和零值不一样
+	d = user{}
+	assert.NoError(t, c.MaxDepth(0).Copy(&d, &user{Name: "c"}))
+	assert.Equal(t, user{}, d)
+}
@@ -0,0 +1,99 @@
+package dcopy
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试错误里的字段路径和类型
+func Test_Error_Path(t *testing.T) {
+	type item struct {
+		Price int64
+	}
+
+	type order struct {
+		Items map[string]item
+	}
+
+	type itemDTO struct {
+		Price int8
+	}
+
+	type orderDTO struct {
+		Items map[string]itemDTO
+	}
+
+	src := struct{ Orders []order }{
+		Orders: []order{{}, {}, {}, {Items: map[string]item{"sku": {Price: 1000}}}},
+	}
+
+	var dst struct{ Orders []orderDTO }
+	err := Copy(&dst, &src).OverflowMode(OverflowError).Do()
+	assert.True(t, errors.Is(err, ErrOverflow))
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, `Orders[3].Items["sku"].Price`, e.Path)
+	assert.Equal(t, reflect.TypeOf(int8(0)), e.DstType)
+	assert.Equal(t, reflect.TypeOf(int64(0)), e.SrcType)
+	assert.True(t, strings.HasPrefix(err.Error(), `dcopy: field Orders[3].Items["sku"].Price: `))
+}
+
+// 测试参数错误
+func Test_Error_Args(t *testing.T) {
+	var i int
+	assert.True(t, errors.Is(Copy(nil, &i).Do(), ErrNil))
+	assert.True(t, errors.Is(Copy(i, &i).Do(), ErrNotPointer))
+	assert.True(t, errors.Is(Copy((*int)(nil), &i).Do(), ErrNotAddressable))
+
+	var e *Error
+	assert.True(t, errors.As(Copy(i, &i).Do(), &e))
+	assert.Equal(t, reflect.TypeOf(0), e.DstType)
+}
+
+// 测试ContinueOnError
+func Test_Error_ContinueOnError(t *testing.T) {
+	type src struct {
+		A int64
+		B string
+		C int64
+	}
+
+	type dst struct {
+		A int8
+		B string
+		C int8
+	}
+
+	s := src{A: 1000, B: "b", C: -1000}
+
+	// 默认遇到第一个错误就停止
+	var d dst
+	err := Copy(&d, &s).OverflowMode(OverflowError).Do()
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, "A", e.Path)
+	assert.Equal(t, "", d.B)
+
+	d = dst{}
+	err = Copy(&d, &s).OverflowMode(OverflowError).ContinueOnError().Do()
+	var m MultiError
+	assert.True(t, errors.As(err, &m))
+	assert.Len(t, m, 2)
+	assert.True(t, errors.Is(err, ErrOverflow))
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, "A", e.Path)
+	assert.True(t, errors.As(m[1], &e))
+	assert.Equal(t, "C", e.Path)
+	assert.Equal(t, "b", d.B)
+	assert.True(t, strings.HasPrefix(err.Error(), "dcopy: 2 errors: "))
+
+	// 没有错误返回nil
+	s = src{A: 1, B: "b", C: 2}
+	assert.NoError(t, Copy(&d, &s).OverflowMode(OverflowError).ContinueOnError().Do())
+	assert.Equal(t, dst{A: 1, B: "b", C: 2}, d)
+}
@@ -0,0 +1,68 @@
+package dcopy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试字段的配对方式
+func Test_FieldMatcher(t *testing.T) {
+	type generated struct {
+		UserId   int
+		UserName string
+		Age      int
+	}
+
+	type handWritten struct {
+		UserID   int
+		Username string
+		AGE      int
+	}
+
+	src := generated{UserId: 1, UserName: "name", Age: 18}
+
+	// 默认完全相同才拷贝
+	var d handWritten
+	err := Copy(&d, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, handWritten{}, d)
+
+	d = handWritten{}
+	err = Copy(&d, &src).FieldMatcher(MatchCaseInsensitive).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, handWritten{UserID: 1, Username: "name", AGE: 18}, d)
+
+	// 下划线风格的tag和驼峰风格的字段名
+	type row struct {
+		UserID   int    `db:"user_id"`
+		UserName string `db:"user_name"`
+	}
+
+	var r row
+	err = Copy(&r, &src).FieldMatcher(MatchNormalized).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, row{UserID: 1, UserName: "name"}, r)
+
+	type snake struct {
+		ID   int    `db:"user_id"`
+		Name string `db:"user_name"`
+	}
+
+	var s snake
+	err = Copy(&s, &r).RegisterTagName("db").FieldMatcher(MatchNormalized).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, snake{ID: 1, Name: "name"}, s)
+
+	// 自定义配对方式
+	type prefixed struct {
+		XUserId int
+	}
+
+	var p prefixed
+	trim := FieldMatcherFunc(func(name string) string { return strings.TrimPrefix(name, "X") })
+	err = Copy(&p, &src).FieldMatcher(trim).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, prefixed{XUserId: 1}, p)
+}
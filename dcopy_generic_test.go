@@ -0,0 +1,107 @@
+//go:build go1.21
+// +build go1.21
+
+package dcopy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试泛型接口
+func Test_Generic_Map(t *testing.T) {
+	type user struct {
+		ID   int64
+		Name string
+		Tags []string
+	}
+
+	type userDTO struct {
+		ID   int
+		Name string
+		Tags []string
+	}
+
+	src := user{ID: 1, Name: "a", Tags: []string{"x"}}
+	dto, err := Map[userDTO](src)
+	assert.NoError(t, err)
+	assert.Equal(t, userDTO{ID: 1, Name: "a", Tags: []string{"x"}}, dto)
+
+	dto.Tags[0] = "y"
+	assert.Equal(t, "x", src.Tags[0])
+
+	dto, err = MapWith[userDTO](NewCopier().IgnoreZero(), user{Name: "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, userDTO{Name: "b"}, dto)
+
+	var d userDTO
+	assert.NoError(t, CopyOf(&d, &src))
+	assert.Equal(t, "a", d.Name)
+	assert.True(t, errors.Is(CopyOf((*userDTO)(nil), &src), ErrNil))
+
+	// 指针
+	p, err := Map[*userDTO](&src)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", p.Name)
+}
+
+// 测试泛型Clone
+func Test_Generic_CloneOf(t *testing.T) {
+	type node struct {
+		Name     string
+		Children []*node
+		Attrs    map[string]interface{}
+	}
+
+	src := &node{Name: "root", Attrs: map[string]interface{}{"n": []int{1}}}
+	src.Children = []*node{{Name: "child"}}
+
+	dst, err := CloneOf(src)
+	assert.NoError(t, err)
+	assert.Equal(t, src, dst)
+	assert.True(t, dst != src)
+	assert.True(t, dst.Children[0] != src.Children[0])
+
+	dst.Attrs["n"].([]int)[0] = 2
+	assert.Equal(t, 1, src.Attrs["n"].([]int)[0])
+
+	m, err := CloneOf(map[string][]int{"a": {1}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]int{"a": {1}}, m)
+}
+
+// 泛型接口使用缓存, 第二次调用不再编译计划
+func Test_Generic_Cache(t *testing.T) {
+	type genSrc struct {
+		ID   int64
+		Tags []string
+	}
+
+	type genDst struct {
+		ID   int
+		Tags []string
+	}
+
+	stats := CacheStats()
+	dst, err := Map[genDst](genSrc{ID: 1, Tags: []string{"a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, genDst{ID: 1, Tags: []string{"a"}}, dst)
+	assert.Equal(t, stats.Builds+1, CacheStats().Builds)
+
+	stats = CacheStats()
+	assert.NoError(t, CopyOf(&dst, &genSrc{ID: 2}))
+	assert.Equal(t, genDst{ID: 2}, dst)
+	assert.Equal(t, stats.Builds, CacheStats().Builds)
+	assert.Equal(t, stats.Hits+1, CacheStats().Hits)
+
+	// Copier打开了缓存时使用Copier自己的缓存
+	c := NewCopier().Cache()
+	for i := 0; i < 2; i++ {
+		dst, err = MapWith[genDst](c, genSrc{ID: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, genDst{ID: 3}, dst)
+	}
+	assert.Equal(t, PlanCacheStats{Hits: 1, Misses: 1, Builds: 1, Len: 1, Size: defaultCacheSize}, c.CacheStats())
+}
@@ -0,0 +1,141 @@
+package dcopy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 金额, 币种和数值必须一起拷贝
+type hookMoney struct {
+	Currency string
+	Amount   int64
+}
+
+func (m *hookMoney) CopyFrom(src interface{}) error {
+	switch s := src.(type) {
+	case hookMoney:
+		*m = s
+	case int64:
+		if s < 0 {
+			return errors.New("negative amount")
+		}
+		*m = hookMoney{Currency: "CNY", Amount: s}
+	}
+	return nil
+}
+
+type hookVersion struct {
+	Major, Minor int
+	raw          string
+	called       bool
+}
+
+func (in *hookVersion) DeepCopyInto(out *hookVersion) {
+	*out = *in
+	out.called = true
+}
+
+// 测试类型自己实现的拷贝方法
+func Test_DeepCopier(t *testing.T) {
+	type order struct {
+		Price   hookMoney
+		Version hookVersion
+	}
+
+	type orderReq struct {
+		Price   int64
+		Version hookVersion
+	}
+
+	var d order
+	err := Copy(&d, &orderReq{Price: 100, Version: hookVersion{Major: 1, Minor: 2, raw: "1.2"}}).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, order{
+		Price:   hookMoney{Currency: "CNY", Amount: 100},
+		Version: hookVersion{Major: 1, Minor: 2, raw: "1.2", called: true},
+	}, d)
+
+	// CopyFrom返回的错误
+	err = Copy(&d, &orderReq{Price: -1}).Do()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field Price: negative amount")
+
+	// slice里的元素也会调用
+	src := []hookMoney{{Currency: "USD", Amount: 1}}
+	var dst []hookMoney
+	err = Copy(&dst, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
+
+type hookUser struct {
+	Name     string
+	FullName string
+	Age      int
+}
+
+func (u *hookUser) BeforeCopy(src interface{}) error {
+	if s, ok := src.(hookUserReq); ok && s.Age < 0 {
+		return errors.New("invalid age")
+	}
+	return nil
+}
+
+func (u *hookUser) AfterCopy(src interface{}) error {
+	u.FullName = "Mr. " + u.Name
+	return nil
+}
+
+type hookUserReq struct {
+	Name string
+	Age  int
+}
+
+// 测试拷贝前后的回调
+func Test_BeforeAfterCopy(t *testing.T) {
+	type team struct {
+		Leader  hookUser
+		Members []hookUser
+	}
+
+	type teamReq struct {
+		Leader  hookUserReq
+		Members []hookUserReq
+	}
+
+	var d team
+	err := Copy(&d, &teamReq{Leader: hookUserReq{Name: "a"}, Members: []hookUserReq{{Name: "b", Age: 1}}}).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, team{
+		Leader:  hookUser{Name: "a", FullName: "Mr. a"},
+		Members: []hookUser{{Name: "b", FullName: "Mr. b", Age: 1}},
+	}, d)
+
+	err = Copy(&d, &teamReq{Members: []hookUserReq{{Name: "b", Age: -1}}}).Do()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field Members[0]: invalid age")
+
+	// 给不能修改的类型注册回调
+	var order []string
+	var r hookUserReq
+	err = Copy(&r, &hookUser{Name: " name "}).
+		BeforeCopy(&r, func(dst, src interface{}) error {
+			order = append(order, "before")
+			return nil
+		}).
+		AfterCopy(hookUserReq{}, func(dst, src interface{}) error {
+			order = append(order, "after")
+			dst.(*hookUserReq).Name = strings.TrimSpace(dst.(*hookUserReq).Name)
+			return nil
+		}).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, hookUserReq{Name: "name"}, r)
+	assert.Equal(t, []string{"before", "after"}, order)
+
+	errStop := errors.New("stop")
+	err = Copy(&r, &hookUser{}).AfterCopy(&r, func(dst, src interface{}) error { return errStop }).Do()
+	assert.True(t, errors.Is(err, errStop))
+}
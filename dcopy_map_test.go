@@ -103,3 +103,37 @@ func Test_Map_Special(t *testing.T) {
 		assert.Equal(t, tc.need, tc.got)
 	}
 }
+
+// 异构map拷贝, key相同, value是不同的结构体
+func Test_Map_Heterogeneous(t *testing.T) {
+	type userDO struct {
+		ID       int
+		Name     string
+		Password string
+	}
+
+	type userDTO struct {
+		Name string
+		ID   int
+	}
+
+	src := map[string]userDO{
+		"1": {ID: 1, Name: "name:1", Password: "1"},
+		"2": {ID: 2, Name: "name:2", Password: "2"},
+	}
+
+	var dst map[string]userDTO
+	err := Copy(&dst, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]userDTO{
+		"1": {ID: 1, Name: "name:1"},
+		"2": {ID: 2, Name: "name:2"},
+	}, dst)
+
+	// value是指针
+	srcPtr := map[string]*userDO{"1": {ID: 1, Name: "name:1"}}
+	var dstPtr map[string]*userDTO
+	err = Copy(&dstPtr, &srcPtr).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*userDTO{"1": {ID: 1, Name: "name:1"}}, dstPtr)
+}
@@ -0,0 +1,133 @@
+package dcopy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type profile struct {
+	Bio     string
+	Website string
+}
+
+type entity struct {
+	ID      int
+	Name    string
+	Age     int
+	Tags    []string
+	Extra   map[string]string
+	Profile profile
+	Ptr     *profile
+	Active  bool
+}
+
+type patchReq struct {
+	Name    string
+	Age     int
+	Tags    []string
+	Extra   map[string]string
+	Profile profile
+	Ptr     *profile
+}
+
+func defaultEntity() entity {
+	return entity{
+		ID:      1,
+		Name:    "old",
+		Age:     18,
+		Tags:    []string{"a"},
+		Extra:   map[string]string{"k": "v"},
+		Profile: profile{Bio: "bio", Website: "http://old"},
+		Ptr:     &profile{Bio: "bio", Website: "http://old"},
+		Active:  true,
+	}
+}
+
+// 测试补丁模式
+func Test_IgnoreZero(t *testing.T) {
+	e := defaultEntity()
+	err := Copy(&e, &patchReq{Name: "new"}).IgnoreZero().Do()
+	assert.NoError(t, err)
+
+	need := defaultEntity()
+	need.Name = "new"
+	assert.Equal(t, need, e)
+
+	// 嵌套的结构体和指针只更新非零值的字段
+	ptr := e.Ptr
+	err = Copy(&e, &patchReq{Profile: profile{Website: "http://new"}, Ptr: &profile{Bio: "new bio"}}).IgnoreZero().Do()
+	assert.NoError(t, err)
+	need.Profile.Website = "http://new"
+	need.Ptr.Bio = "new bio"
+	assert.Equal(t, need, e)
+	assert.Same(t, ptr, e.Ptr)
+
+	// 默认零值会覆盖
+	err = Copy(&e, &patchReq{Name: "new"}).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, e.Age)
+	assert.Nil(t, e.Ptr)
+}
+
+// 测试补丁模式和MaxDepth一起使用
+func Test_IgnoreZero_MaxDepth(t *testing.T) {
+	e := defaultEntity()
+	err := Copy(&e, &patchReq{Name: "new", Profile: profile{Bio: "new bio"}}).IgnoreZero().MaxDepth(1).Do()
+	assert.NoError(t, err)
+
+	need := defaultEntity()
+	need.Name = "new"
+	assert.Equal(t, need, e)
+}
+
+type timedEntity struct {
+	Name    string
+	Updated time.Time
+	note    string
+}
+
+// 测试补丁模式下的time.Time和小写字段, 零值不覆盖dst
+func Test_IgnoreZero_Unexported(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	for _, c := range []*Copier{NewCopier().IgnoreZero(), NewCopier().IgnoreZero().Cache()} {
+		for i := 0; i < 2; i++ {
+			e := timedEntity{Name: "a", Updated: now, note: "n"}
+			assert.NoError(t, c.Copy(&e, &timedEntity{Name: "b"}))
+			assert.Equal(t, timedEntity{Name: "b", Updated: now, note: "n"}, e)
+
+			// 非零值整个覆盖
+			assert.NoError(t, c.Copy(&e, &timedEntity{Updated: later, note: "m"}))
+			assert.Equal(t, timedEntity{Name: "b", Updated: later, note: "m"}, e)
+
+			// 没有单调时钟, 纳秒是0的时间也整个覆盖
+			date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			assert.NoError(t, c.Copy(&e, &timedEntity{Updated: date}))
+			assert.True(t, date.Equal(e.Updated))
+			assert.Equal(t, date, e.Updated)
+		}
+	}
+}
+
+// 测试补丁模式下结构体拷贝到map, 零值的字段不覆盖map里已有的key
+func Test_IgnoreZero_StructToMap(t *testing.T) {
+	type pzA struct {
+		Name    string
+		Age     int
+		Updated time.Time
+	}
+
+	now := time.Now()
+	for _, c := range []*Copier{NewCopier().IgnoreZero(), NewCopier().IgnoreZero().Cache()} {
+		m := map[string]interface{}{"Name": "old", "Age": 10, "Updated": now}
+		assert.NoError(t, c.Copy(&m, &pzA{Name: "new"}))
+		assert.Equal(t, map[string]interface{}{"Name": "new", "Age": 10, "Updated": now}, m)
+
+		// 只包含非零值的字段, 可以用来拼UPDATE语句
+		m = map[string]interface{}{}
+		assert.NoError(t, c.Copy(&m, &pzA{Age: 11}))
+		assert.Equal(t, map[string]interface{}{"Age": 11}, m)
+	}
+}
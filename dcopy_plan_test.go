@@ -0,0 +1,147 @@
+package dcopy
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type planNode struct {
+	Name string
+	Next *planNode
+}
+
+type planSrc struct {
+	ID     int32
+	Tags   []string
+	Items  []planItem
+	Attrs  map[string]*planItem
+	Any    interface{}
+	Node   *planNode
+	Matrix [2][]int
+	Price  int64
+}
+
+type planItem struct {
+	SKU   string
+	Count int
+}
+
+type planDst struct {
+	ID     int64
+	Tags   []string
+	Items  []planItemDTO
+	Attrs  map[string]*planItemDTO
+	Any    interface{}
+	Node   *planNode
+	Matrix [2][]int64
+	Price  string
+}
+
+type planItemDTO struct {
+	SKU   string
+	Count int8
+}
+
+func newPlanSrc(n int) planSrc {
+	s := planSrc{ID: int32(n), Any: &planItem{SKU: "any"}, Node: &planNode{Name: "a"}}
+	for i := 0; i < n; i++ {
+		s.Tags = append(s.Tags, strconv.Itoa(i))
+		s.Items = append(s.Items, planItem{SKU: strconv.Itoa(i), Count: i})
+		s.Matrix[i%2] = append(s.Matrix[i%2], i)
+	}
+	s.Attrs = map[string]*planItem{"k": {SKU: "v", Count: n}}
+	s.Node.Next = s.Node
+	return s
+}
+
+// 计划拷贝和反射拷贝的结果一样, slice的长度每次不同也没有问题
+func Test_Plan_SameAsReflect(t *testing.T) {
+	c := NewCopier().Cache().ConvertString()
+	for _, n := range []int{3, 0, 5, 1} {
+		src := newPlanSrc(n)
+
+		var want, got planDst
+		assert.NoError(t, NewCopier().ConvertString().Copy(&want, &src))
+		assert.NoError(t, c.Copy(&got, &src))
+		assert.Equal(t, want.ID, got.ID)
+		assert.Equal(t, want.Tags, got.Tags)
+		assert.Equal(t, want.Items, got.Items)
+		assert.Equal(t, want.Attrs, got.Attrs)
+		assert.Equal(t, want.Any, got.Any)
+		assert.Equal(t, want.Matrix, got.Matrix)
+		assert.Equal(t, want.Price, got.Price)
+		assert.Equal(t, len(src.Items), len(got.Items))
+
+		// 循环引用
+		assert.True(t, got.Node.Next == got.Node)
+		assert.True(t, got.Node != src.Node)
+		assert.True(t, got.Any.(*planItem) != src.Any.(*planItem))
+	}
+}
+
+// interface里的值的类型每次不同
+func Test_Plan_Interface(t *testing.T) {
+	type box struct {
+		V interface{}
+	}
+
+	c := NewCopier().Cache()
+	for _, v := range []interface{}{1, "s", []int{1, 2}, map[string]int{"a": 1}, &planItem{SKU: "x"}, nil} {
+		var d box
+		assert.NoError(t, c.Copy(&d, &box{V: v}))
+		assert.Equal(t, v, d.V)
+	}
+}
+
+// 计划里的错误也包含字段路径
+func Test_Plan_Error(t *testing.T) {
+	c := NewCopier().Cache().OverflowMode(OverflowError)
+	for i := 0; i < 2; i++ {
+		src := planSrc{Items: []planItem{{Count: 1}, {Count: 1000}}}
+
+		var d planDst
+		err := c.Copy(&d, &src)
+		var e *Error
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, "Items[1].Count", e.Path)
+	}
+}
+
+// 计划里也会调用回调和转换函数
+func Test_Plan_HookAndConverter(t *testing.T) {
+	calls := 0
+	c := NewCopier().Cache().
+		Converter(func(p int64) string { return "$" + strconv.FormatInt(p, 10) }).
+		AfterCopy((*planItemDTO)(nil), func(dst, src interface{}) error {
+			calls++
+			return nil
+		})
+
+	for i := 0; i < 2; i++ {
+		src := planSrc{Price: 5, Items: []planItem{{SKU: "a"}}}
+
+		var d planDst
+		assert.NoError(t, c.Copy(&d, &src))
+		assert.Equal(t, "$5", d.Price)
+		assert.Equal(t, "a", d.Items[0].SKU)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+// 设置了MaxDepth时, 同一个类型在不同的层次计划不一样
+func Test_Plan_MaxDepth(t *testing.T) {
+	c := NewCopier().Cache().MaxDepth(2)
+	for i := 0; i < 2; i++ {
+		src := &planNode{Name: "a", Next: &planNode{Name: "b", Next: &planNode{Name: "c"}}}
+
+		var want, got planNode
+		assert.NoError(t, Copy(&want, src).MaxDepth(2).Do())
+		assert.NoError(t, c.Copy(&got, src))
+		assert.Equal(t, want, got)
+		assert.Equal(t, "b", got.Next.Name)
+		assert.Equal(t, "", got.Next.Next.Name)
+	}
+}
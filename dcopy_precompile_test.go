@@ -0,0 +1,92 @@
+package dcopy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试提前编译计划
+func Test_Precompile(t *testing.T) {
+	c := NewCopier().Cache()
+	assert.NoError(t, c.Precompile(reflect.TypeOf(planItemDTO{}), reflect.TypeOf(planItem{})))
+	// 提前编译不计入命中和未命中
+	assert.Equal(t, PlanCacheStats{Builds: 1, Len: 1, Size: defaultCacheSize}, c.CacheStats())
+	assert.NoError(t, c.Precompile(reflect.TypeOf(planItemDTO{}), reflect.TypeOf(planItem{})))
+	assert.Equal(t, PlanCacheStats{Builds: 1, Len: 1, Size: defaultCacheSize}, c.CacheStats())
+
+	// 拷贝时直接使用编译好的计划
+	var d planItemDTO
+	assert.NoError(t, c.Copy(&d, &planItem{SKU: "a", Count: 1}))
+	assert.Equal(t, planItemDTO{SKU: "a", Count: 1}, d)
+	assert.Equal(t, uint64(1), c.CacheStats().Builds)
+	assert.Equal(t, uint64(1), c.CacheStats().Hits)
+
+	assert.NoError(t, c.PrecompileValue(planDst{}, planSrc{}))
+	assert.Equal(t, uint64(2), c.CacheStats().Builds)
+
+	// 共享缓存
+	before := CacheStats().Builds
+	assert.NoError(t, PrecompileValue(planItemDTO{}, &planItem{}))
+	assert.Equal(t, before+1, CacheStats().Builds)
+
+	// 没有打开缓存时只编译, 不保存
+	noCache := NewCopier()
+	assert.NoError(t, noCache.PrecompileValue(planItemDTO{}, planItem{}))
+	assert.Equal(t, PlanCacheStats{}, noCache.CacheStats())
+
+	assert.True(t, errors.Is(c.Precompile(nil, reflect.TypeOf(1)), ErrNil))
+	assert.Error(t, NewCopier().Converter(1).PrecompileValue(1, 1))
+}
+
+// 测试严格模式下提前检查类型的映射
+func Test_Precompile_Strict(t *testing.T) {
+	type item struct {
+		SKU   string
+		Price string
+		Ch    chan int
+	}
+
+	type order struct {
+		ID    int
+		Items []item
+		Next  *order
+		Old   string
+	}
+
+	type itemDTO struct {
+		SKU   string
+		Price float64
+		Ch    int
+	}
+
+	type orderDTO struct {
+		ID    int64
+		Items []itemDTO
+		Next  *orderDTO
+		New   string
+	}
+
+	c := NewCopier().Strict()
+	err := c.PrecompileValue(orderDTO{}, order{})
+
+	var e *StrictError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, []string{"New"}, e.UnmatchedDst)
+	assert.Equal(t, []string{"Old"}, e.UnmatchedSrc)
+	assert.Equal(t, []Mismatch{
+		{Path: "Items[].Price", DstType: reflect.TypeOf(float64(0)), SrcType: reflect.TypeOf("")},
+		{Path: "Items[].Ch", DstType: reflect.TypeOf(0), SrcType: reflect.TypeOf(make(chan int))},
+	}, e.Mismatched)
+
+	// 打开ConvertString之后string可以转换成float64
+	err = c.ConvertString().PrecompileValue(orderDTO{}, order{})
+	assert.True(t, errors.As(err, &e))
+	assert.Len(t, e.Mismatched, 1)
+
+	// 非严格模式不检查
+	assert.NoError(t, NewCopier().PrecompileValue(orderDTO{}, order{}))
+	assert.NoError(t, c.PrecompileValue(itemDTO{SKU: "a"}, itemDTO{}))
+}
@@ -31,6 +31,36 @@ func Test_Ptr_OK(t *testing.T) {
 	}
 }
 
+// 测试指针是深度拷贝, dst和src不共享指向的数据
+func Test_Ptr_Deep(t *testing.T) {
+	type core struct {
+		Name string
+	}
+
+	type ptrTest struct {
+		Iptr *int
+		Core *core
+		Nil  *int
+	}
+
+	n := 3
+	src := ptrTest{Iptr: &n, Core: &core{Name: "hello"}}
+	d := ptrTest{Nil: new(int)}
+
+	err := Copy(&d, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, src, d)
+
+	assert.NotSame(t, src.Iptr, d.Iptr)
+	assert.NotSame(t, src.Core, d.Core)
+	assert.Nil(t, d.Nil)
+
+	*d.Iptr = 4
+	d.Core.Name = "world"
+	assert.Equal(t, 3, *src.Iptr)
+	assert.Equal(t, "hello", src.Core.Name)
+}
+
 // 测试指针特殊情况
 // 只要不崩溃就是对的
 func Test_Ptr_Special(t *testing.T) {
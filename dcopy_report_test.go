@@ -0,0 +1,68 @@
+package dcopy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试拷贝报告
+func Test_DoWithReport(t *testing.T) {
+	type inner struct {
+		Deep string
+	}
+
+	type src struct {
+		ID     int32
+		Name   string `copy:"name"`
+		Secret string `copy:"-"`
+		Ptr    *int
+		Inner  inner
+		Extra  string
+		Kind   string
+	}
+
+	type dst struct {
+		ID    int64
+		Name  string `copy:"name"`
+		Ptr   *int
+		Inner inner
+		Kind  []int
+	}
+
+	s := src{ID: 1, Name: "name", Secret: "x", Inner: inner{Deep: "deep"}, Extra: "extra", Kind: "k"}
+	var d dst
+	report, err := Copy(&d, &s).RegisterTagName("copy").DoWithReport()
+	assert.NoError(t, err)
+	assert.Equal(t, []ReportEntry{
+		{Path: "ID", Action: ActionSkippedByTag, SrcType: reflect.TypeOf(int32(0))},
+		{Path: "Name", Action: ActionCopied, DstType: reflect.TypeOf(""), SrcType: reflect.TypeOf("")},
+		{Path: "Secret", Action: ActionSkippedByTag, SrcType: reflect.TypeOf("")},
+		{Path: "Ptr", Action: ActionSkippedByTag, SrcType: reflect.TypeOf((*int)(nil))},
+		{Path: "Inner", Action: ActionSkippedByTag, SrcType: reflect.TypeOf(inner{})},
+		{Path: "Extra", Action: ActionSkippedByTag, SrcType: reflect.TypeOf("")},
+		{Path: "Kind", Action: ActionSkippedByTag, SrcType: reflect.TypeOf("")},
+	}, report.Entries)
+
+	d = dst{}
+	report, err = Copy(&d, &s).MaxDepth(1).DoWithReport()
+	assert.NoError(t, err)
+	assert.Equal(t, []ReportEntry{
+		{Path: "ID", Action: ActionConverted, DstType: reflect.TypeOf(int64(0)), SrcType: reflect.TypeOf(int32(0))},
+		{Path: "Name", Action: ActionCopied, DstType: reflect.TypeOf(""), SrcType: reflect.TypeOf("")},
+		{Path: "Secret", Action: ActionUnmatched, SrcType: reflect.TypeOf("")},
+		{Path: "Ptr", Action: ActionNilSource, DstType: reflect.TypeOf((*int)(nil)), SrcType: reflect.TypeOf((*int)(nil))},
+		{Path: "Inner.Deep", Action: ActionSkippedByDepth, DstType: reflect.TypeOf(""), SrcType: reflect.TypeOf("")},
+		{Path: "Extra", Action: ActionUnmatched, SrcType: reflect.TypeOf("")},
+		{Path: "Kind", Action: ActionKindMismatch, DstType: reflect.TypeOf([]int{}), SrcType: reflect.TypeOf("")},
+	}, report.Entries)
+	assert.Len(t, report.Filter(ActionUnmatched), 2)
+	assert.Contains(t, report.String(), "Kind: kind-mismatch ([]int <- string)")
+
+	// 出错时也返回报告
+	report, err = Copy(&d, &s).AfterCopy(&d, func(dst, src interface{}) error { return errors.New("after") }).DoWithReport()
+	assert.Error(t, err)
+	assert.NotEmpty(t, report.Entries)
+}
@@ -0,0 +1,53 @@
+package dcopy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试元素类型不同, 大小也不同的slice
+func Test_Slice_Heterogeneous(t *testing.T) {
+	type srcRow struct {
+		ID      int64
+		Name    string
+		Comment string
+		Score   [4]int
+	}
+
+	type dstRow struct {
+		Name  string
+		ID    int64
+		Score [2]int
+	}
+
+	src := []srcRow{
+		{ID: 1, Name: "a", Comment: "aa", Score: [4]int{1, 2, 3, 4}},
+		{ID: 2, Name: "b", Comment: "bb", Score: [4]int{5, 6, 7, 8}},
+		{ID: 3, Name: "c", Comment: "cc"},
+	}
+
+	need := []dstRow{
+		{ID: 1, Name: "a", Score: [2]int{1, 2}},
+		{ID: 2, Name: "b", Score: [2]int{5, 6}},
+		{ID: 3, Name: "c"},
+	}
+
+	var dst []dstRow
+	err := Copy(&dst, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, need, dst)
+
+	// slice to array
+	var dstArray [2]dstRow
+	err = Copy(&dstArray, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, [2]dstRow{need[0], need[1]}, dstArray)
+
+	// array to slice
+	srcArray := [3]srcRow{src[0], src[1], src[2]}
+	dst = nil
+	err = Copy(&dst, &srcArray).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, need, dst)
+}
@@ -0,0 +1,150 @@
+package dcopy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type strategyItem struct {
+	ID   int
+	Name string
+}
+
+type strategyData struct {
+	S []int
+	M map[string]int
+	I []strategyItem
+	P map[string]strategyItem
+}
+
+func strategyDst() strategyData {
+	return strategyData{
+		S: []int{1, 2, 3},
+		M: map[string]int{"a": 1, "b": 2},
+		I: []strategyItem{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}},
+		P: map[string]strategyItem{"a": {ID: 1, Name: "one"}},
+	}
+}
+
+func strategySrc() strategyData {
+	return strategyData{
+		S: []int{10},
+		M: map[string]int{"b": 20, "c": 30},
+		I: []strategyItem{{ID: 10}},
+		P: map[string]strategyItem{"a": {ID: 10}},
+	}
+}
+
+// 测试map和slice的合并策略
+func Test_Strategy(t *testing.T) {
+	for _, tc := range []struct {
+		strategy Strategy
+		need     strategyData
+	}{
+		{
+			strategy: StrategyDefault,
+			need: strategyData{
+				S: []int{10},
+				M: map[string]int{"a": 1, "b": 20, "c": 30},
+				I: []strategyItem{{ID: 10}},
+				P: map[string]strategyItem{"a": {ID: 10}},
+			},
+		},
+		{
+			strategy: StrategyReplace,
+			need:     strategySrc(),
+		},
+		{
+			strategy: StrategyMerge,
+			need: strategyData{
+				S: []int{1, 2, 3, 10},
+				M: map[string]int{"a": 1, "b": 20, "c": 30},
+				I: []strategyItem{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}, {ID: 10}},
+				P: map[string]strategyItem{"a": {ID: 10}},
+			},
+		},
+		{
+			strategy: StrategyMergeKeep,
+			need: strategyData{
+				S: []int{1, 2, 3, 10},
+				M: map[string]int{"a": 1, "b": 2, "c": 30},
+				I: []strategyItem{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}, {ID: 10}},
+				P: map[string]strategyItem{"a": {ID: 1, Name: "one"}},
+			},
+		},
+		{
+			strategy: StrategyOverlay,
+			need: strategyData{
+				S: []int{10, 2, 3},
+				M: map[string]int{"a": 1, "b": 20, "c": 30},
+				I: []strategyItem{{ID: 10, Name: "one"}, {ID: 2, Name: "two"}},
+				P: map[string]strategyItem{"a": {ID: 10, Name: "one"}},
+			},
+		},
+	} {
+		d := strategyDst()
+		s := strategySrc()
+		err := Copy(&d, &s).Strategy(tc.strategy).IgnoreZero().Do()
+		assert.NoError(t, err)
+		assert.Equal(t, tc.need, d, "strategy:%d", tc.strategy)
+	}
+}
+
+// 测试追加时不改写dst原来的底层数组
+func Test_Strategy_Append(t *testing.T) {
+	backing := make([]int, 2, 10)
+	backing[0], backing[1] = 1, 2
+	d := backing[:2]
+	s := []int{3}
+
+	err := Copy(&d, &s).Strategy(StrategyMerge).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, d)
+	assert.Equal(t, 0, backing[:3][2])
+}
+
+// 测试在tag里设置字段的合并策略
+func Test_Strategy_Tag(t *testing.T) {
+	type data struct {
+		Logs []string          `copy:"logs,append"`
+		Meta map[string]string `copy:",replace"`
+		Tags []string          `copy:"tags"`
+	}
+
+	d := data{Logs: []string{"1"}, Meta: map[string]string{"a": "1"}, Tags: []string{"x", "y"}}
+	s := data{Logs: []string{"2"}, Meta: map[string]string{"b": "2"}, Tags: []string{"z"}}
+	err := Copy(&d, &s).RegisterTagName("copy").Do()
+	assert.NoError(t, err)
+	assert.Equal(t, data{Logs: []string{"1", "2"}, Meta: map[string]string{"b": "2"}, Tags: []string{"z"}}, d)
+
+	// 字段上的策略优先
+	d = data{Logs: []string{"1"}, Tags: []string{"x", "y"}}
+	err = Copy(&d, &s).RegisterTagName("copy").Strategy(StrategyOverlay).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, data{Logs: []string{"1", "2"}, Meta: map[string]string{"b": "2"}, Tags: []string{"z", "y"}}, d)
+}
+
+// 没有RegisterTagName时也读取字段的合并策略, 没有tag的字段照常拷贝
+func Test_Strategy_Tag_NoTagName(t *testing.T) {
+	type src struct {
+		Logs []string
+		Name string
+	}
+
+	type dst struct {
+		Logs []string `copy:",append"`
+		Name string
+	}
+
+	for _, c := range []*Copier{NewCopier(), NewCopier().Cache()} {
+		d := dst{Logs: []string{"1"}}
+		assert.NoError(t, c.Copy(&d, &src{Logs: []string{"2"}, Name: "a"}))
+		assert.Equal(t, dst{Logs: []string{"1", "2"}, Name: "a"}, d)
+	}
+
+	// tag的名字部分为空时, 设置了tag name也按字段名配对
+	d := dst{Logs: []string{"1"}}
+	assert.NoError(t, Copy(&d, &dst{Logs: []string{"2"}, Name: "a"}).RegisterTagName("copy").Do())
+	assert.Equal(t, dst{Logs: []string{"1", "2"}}, d)
+}
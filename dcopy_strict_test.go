@@ -0,0 +1,80 @@
+package dcopy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试严格模式
+func Test_Strict(t *testing.T) {
+	type item struct {
+		SKU   string
+		Price string
+	}
+
+	type order struct {
+		ID       int
+		UserName string
+		Items    []item
+		Ignore   string `copy:"-"`
+	}
+
+	type itemDTO struct {
+		SKU   string
+		Price float64
+		Count int
+	}
+
+	type orderDTO struct {
+		ID     int64
+		Name   string
+		Items  []itemDTO
+		Ignore string
+	}
+
+	src := order{ID: 1, UserName: "name", Items: []item{{SKU: "a", Price: "1.5"}}}
+
+	// 默认不检查
+	var d orderDTO
+	err := Copy(&d, &src).Do()
+	assert.NoError(t, err)
+
+	err = Copy(&d, &src).Strict().Do()
+	var se *StrictError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, []string{"Items[0].Count", "Name"}, se.UnmatchedDst)
+	assert.Equal(t, []string{"UserName"}, se.UnmatchedSrc)
+	assert.Equal(t, []Mismatch{{Path: "Items[0].Price", DstType: reflect.TypeOf(float64(0)), SrcType: reflect.TypeOf("")}}, se.Mismatched)
+	assert.Contains(t, err.Error(), "Items[0].Price(float64 <- string)")
+
+	// 打开字符串转换之后, 类型兼容
+	err = Copy(&d, &src).Strict().ConvertString().Do()
+	assert.True(t, errors.As(err, &se))
+	assert.Empty(t, se.Mismatched)
+
+	// 所有字段都配对
+	err = Copy(&order{}, &src).Strict().Do()
+	assert.NoError(t, err)
+
+	// tag是"-"的字段不检查
+	type user struct {
+		ID       int `copy:"id"`
+		Password int `copy:"-"`
+	}
+	type userDTO struct {
+		Uid   int    `copy:"id"`
+		Token string `copy:"-"`
+		Extra string
+	}
+	err = Copy(&userDTO{}, &user{}).Strict().RegisterTagName("copy").Do()
+	assert.NoError(t, err)
+
+	// map拷贝到结构体
+	err = Copy(&itemDTO{}, &map[string]interface{}{"SKU": "a", "Unknown": 1}).Strict().Do()
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, []string{`["Unknown"]`}, se.UnmatchedSrc)
+	assert.ElementsMatch(t, []string{"Price", "Count"}, se.UnmatchedDst)
+}
@@ -0,0 +1,134 @@
+package dcopy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type addr struct {
+	City string
+	Zip  int
+}
+
+type userReq struct {
+	ID      int
+	Name    string `copy:"name"`
+	Tags    []string
+	Addr    addr
+	Created time.Time
+	secret  string
+}
+
+// 测试结构体拷贝到map
+func Test_StructToMap(t *testing.T) {
+	now := time.Now()
+	src := userReq{ID: 1, Name: "name", Tags: []string{"a"}, Addr: addr{City: "sz", Zip: 518000}, Created: now, secret: "x"}
+
+	var m map[string]interface{}
+	err := Copy(&m, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ID":      1,
+		"Name":    "name",
+		"Tags":    []string{"a"},
+		"Addr":    addr{City: "sz", Zip: 518000},
+		"Created": now,
+	}, m)
+
+	// 深度拷贝, 不共享数据
+	m["Tags"].([]string)[0] = "b"
+	assert.Equal(t, "a", src.Tags[0])
+
+	// 使用tag的值做key
+	m = nil
+	err = Copy(&m, &src).RegisterTagName("copy").Do()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "name"}, m)
+
+	// map的value是具体的类型
+	var ms map[string]string
+	err = Copy(&ms, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Name": "name"}, ms)
+
+	// 嵌套的结构体拷贝到嵌套的map
+	type wrap struct {
+		Addr addr
+	}
+	var mm map[string]map[string]interface{}
+	err = Copy(&mm, &wrap{Addr: addr{City: "sz"}}).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]interface{}{"Addr": {"City": "sz", "Zip": 0}}, mm)
+}
+
+// 测试map拷贝到结构体
+func Test_MapToStruct(t *testing.T) {
+	src := map[string]interface{}{
+		"ID":   int64(1),
+		"Name": "name",
+		"Tags": []interface{}{"a", "b"},
+		"Addr": map[string]interface{}{
+			"City": "sz",
+			"Zip":  518000,
+		},
+		"Unknown": 1,
+	}
+
+	var d userReq
+	err := Copy(&d, &src).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, userReq{ID: 1, Name: "name", Tags: []string{"a", "b"}, Addr: addr{City: "sz", Zip: 518000}}, d)
+
+	// key使用FieldMatcher配对
+	d = userReq{}
+	err = Copy(&d, &map[string]string{"id": "1", "name": "name"}).FieldMatcher(MatchCaseInsensitive).ConvertString().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, userReq{ID: 1, Name: "name"}, d)
+
+	// 结构体 -> map -> 结构体
+	now := time.Now()
+	src2 := userReq{ID: 2, Tags: []string{"x"}, Addr: addr{City: "bj"}, Created: now}
+	var m map[string]interface{}
+	err = Copy(&m, &src2).Do()
+	assert.NoError(t, err)
+	d = userReq{}
+	err = Copy(&d, &m).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, src2, d)
+}
+
+// 测试map拷贝到结构体指针字段, 分配新的结构体之后递归拷贝
+func Test_MapToStructPtr(t *testing.T) {
+	type user struct {
+		Name string
+		Addr *addr
+		Home *addr
+	}
+
+	type typedUser struct {
+		Addr *addr
+	}
+
+	m := map[string]interface{}{"Name": "a", "Addr": map[string]interface{}{"City": "sz", "Zip": 518000}, "Home": map[string]interface{}(nil)}
+	for _, c := range []*Copier{NewCopier(), NewCopier().Cache(), NewCopier().Strict()} {
+		var u user
+		assert.NoError(t, c.Copy(&u, &m))
+		assert.Equal(t, user{Name: "a", Addr: &addr{City: "sz", Zip: 518000}}, u)
+	}
+
+	for _, c := range []*Copier{NewCopier(), NewCopier().Cache()} {
+		// 类型确定的map
+		var tu typedUser
+		assert.NoError(t, c.Copy(&tu, &map[string]map[string]string{"Addr": {"City": "bj"}}))
+		assert.Equal(t, typedUser{Addr: &addr{City: "bj"}}, tu)
+	}
+
+	// 补丁模式下在原来的结构体上更新
+	old := &addr{City: "sz", Zip: 518000}
+	u := user{Addr: old}
+	assert.NoError(t, Copy(&u, &map[string]interface{}{"Addr": map[string]interface{}{"City": "gz"}}).IgnoreZero().Do())
+	assert.Same(t, old, u.Addr)
+	assert.Equal(t, addr{City: "gz", Zip: 518000}, *u.Addr)
+}
@@ -162,6 +162,105 @@ func Test_TagName(t *testing.T) {
 	}
 }
 
+// 测试使用tag的值配对字段
+func Test_TagName_Mapping(t *testing.T) {
+	type user struct {
+		UserID   int    `copy:"user_id"`
+		Name     string `copy:"name"`
+		Password string `copy:"-"`
+		Email    string
+	}
+
+	type userDTO struct {
+		Uid      int    `copy:"user_id"`
+		Nickname string `copy:"name"`
+		Password string `copy:"password"`
+		Email    string `copy:"-"`
+	}
+
+	src := user{UserID: 1, Name: "name", Password: "123456", Email: "a@b.c"}
+
+	d := userDTO{}
+	err := Copy(&d, &src).RegisterTagName("copy").Do()
+	assert.NoError(t, err)
+	assert.Equal(t, userDTO{Uid: 1, Nickname: "name"}, d)
+
+	// dst的字段是"-", 不会被覆盖
+	type userEntity struct {
+		UserID int    `copy:"user_id"`
+		Name   string `copy:"-"`
+	}
+
+	e := userEntity{Name: "old"}
+	err = Copy(&e, &src).RegisterTagName("copy").Do()
+	assert.NoError(t, err)
+	assert.Equal(t, userEntity{UserID: 1, Name: "old"}, e)
+}
+
+type promotedBase struct {
+	ID      int
+	Created int64
+}
+
+type promotedMeta struct {
+	promotedBase
+	Version int
+}
+
+type promotedOther struct {
+	Version int
+}
+
+// 测试dst嵌入结构体里提升的字段, 和reflect.Type.FieldByName一样配对
+func Test_PromotedField(t *testing.T) {
+	type src struct {
+		ID      int
+		Created int32
+		Version int
+		Name    string
+	}
+
+	type dst struct {
+		Name string
+		promotedMeta
+	}
+
+	// 同一层有两个Version, 都不使用
+	type ambiguous struct {
+		promotedMeta
+		promotedOther
+	}
+
+	// 指针的嵌入结构体不查找
+	type ptrDst struct {
+		*promotedBase
+		Name string
+	}
+
+	s := src{ID: 7, Created: 9, Version: 2, Name: "a"}
+	for _, c := range []*Copier{NewCopier(), NewCopier().Cache()} {
+		var d dst
+		assert.NoError(t, c.Copy(&d, &s))
+		assert.Equal(t, dst{Name: "a", promotedMeta: promotedMeta{promotedBase: promotedBase{ID: 7, Created: 9}, Version: 2}}, d)
+
+		var a ambiguous
+		assert.NoError(t, c.Copy(&a, &s))
+		assert.Equal(t, ambiguous{promotedMeta: promotedMeta{promotedBase: promotedBase{ID: 7, Created: 9}}}, a)
+
+		var p ptrDst
+		assert.NoError(t, c.Copy(&p, &s))
+		assert.Equal(t, ptrDst{Name: "a"}, p)
+
+		d = dst{}
+		assert.NoError(t, c.Copy(&d, &map[string]interface{}{"ID": 8, "Name": "b"}))
+		assert.Equal(t, dst{Name: "b", promotedMeta: promotedMeta{promotedBase: promotedBase{ID: 8}}}, d)
+	}
+
+	// 严格模式下提升的字段算作已经拷贝
+	var d dst
+	assert.NoError(t, Copy(&d, &s).Strict().Do())
+}
+
 // 下面的test case 确保不panic
 func Test_Special(t *testing.T) {
 	for _, tc := range []testCase{
@@ -205,24 +304,68 @@ func Test_Special(t *testing.T) {
 }
 
 // 测试循环引用
-/*
 func Test_Cycle(t *testing.T) {
-	for _, e := range []error{
-		func() error {
-			type src2 struct {
-				P1 *src2
-				ID string
-			}
+	type node struct {
+		ID   string
+		Next *node
+	}
 
-			// p1指向自己，构造一个环
-			s := src2{}
-			s.P1 = &s
+	// Next指向自己，构造一个环
+	s := node{ID: "1"}
+	s.Next = &s
 
-			d := src2{}
-			return Copy(&d, &s).Do()
-		}(),
-	} {
-		assert.Error(t, e)
+	d := node{}
+	err := Copy(&d, &s).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", d.ID)
+	assert.Equal(t, "1", d.Next.ID)
+	assert.NotSame(t, &s, d.Next)
+	assert.Same(t, d.Next, d.Next.Next)
+
+	// 两个节点组成的环
+	a, b := &node{ID: "a"}, &node{ID: "b"}
+	a.Next, b.Next = b, a
+	d = node{}
+	err = Copy(&d, a).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", d.Next.ID)
+	assert.Equal(t, "a", d.Next.Next.ID)
+	assert.Same(t, d.Next, d.Next.Next.Next)
+}
+
+// 测试共享引用
+func Test_SharedRef(t *testing.T) {
+	type shared struct {
+		P1 *int
+		P2 *int
+		M1 map[string]int
+		M2 map[string]int
+		S1 []int
+		S2 []int
 	}
+
+	n := 3
+	m := map[string]int{"a": 1}
+	sl := []int{1, 2, 3}
+	s := shared{P1: &n, P2: &n, M1: m, M2: m, S1: sl, S2: sl}
+
+	d := shared{}
+	err := Copy(&d, &s).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, s, d)
+	assert.Same(t, d.P1, d.P2)
+	assert.NotSame(t, s.P1, d.P1)
+	d.M1["b"] = 2
+	assert.Equal(t, 2, d.M2["b"])
+	assert.NotContains(t, m, "b")
+	d.S1[0] = 100
+	assert.Equal(t, 100, d.S2[0])
+	assert.Equal(t, 1, sl[0])
+
+	// 关闭检查之后, 共享引用会拷贝成不同的对象
+	d = shared{}
+	err = Copy(&d, &s).NoTrackRef().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, s, d)
+	assert.NotSame(t, d.P1, d.P2)
 }
-*/
@@ -0,0 +1,97 @@
+package dcopy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// 数值转换溢出时返回的错误, 可以使用errors.Is判断
+	ErrOverflow = errors.New("value overflow")
+	// Copy的参数是nil
+	ErrNil = errors.New("unsupported type: nil")
+	// Copy的参数不是指针
+	ErrNotPointer = errors.New("unsupported type: not a pointer")
+	// Copy的参数是空指针, 不能取地址
+	ErrNotAddressable = errors.New("value cannot take address")
+)
+
+// Error 拷贝出错时返回的错误, 包含出错的字段路径和类型
+// 可以使用errors.As取出, errors.Is判断Err
+type Error struct {
+	Path    string // 字段路径, 比如 Orders[3].Items["sku"].Price, 顶层是空字符串
+	DstType reflect.Type
+	SrcType reflect.Type
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("dcopy: field %s: %v", e.Path, e.Err)
+	}
+
+	if e.DstType != nil || e.SrcType != nil {
+		return fmt.Sprintf("dcopy: %v <- %v: %v", e.DstType, e.SrcType, e.Err)
+	}
+	return fmt.Sprintf("dcopy: %v", e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MultiError 打开ContinueOnError之后, 收集到的所有错误, 顺序和访问字段的顺序一样
+type MultiError []error
+
+func (m MultiError) Error() string {
+	if len(m) == 1 {
+		return m[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "dcopy: %d errors: ", len(m))
+	for i, err := range m {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap go1.20之后的errors.Is/As会使用这个方法
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// Is 有一个错误匹配target就返回true, 兼容go1.20之前的errors.Is
+func (m MultiError) Is(target error) bool {
+	for _, err := range m {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 取出第一个匹配target的错误, 兼容go1.20之前的errors.As
+func (m MultiError) As(target interface{}) bool {
+	for _, err := range m {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// 在错误里加上字段路径, ContinueOnError时只记录错误, 继续拷贝
+func (f *dCopy) fieldError(a *args, err error) error {
+	e := &Error{Path: a.path(), DstType: a.dstType, SrcType: a.srcType, Err: err}
+	if f.continueOnError {
+		f.errs = append(f.errs, e)
+		return nil
+	}
+	return e
+}
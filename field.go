@@ -0,0 +1,264 @@
+package dcopy
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// tag的值是"-"时, 不拷贝这个字段
+const tagSkip = "-"
+
+// 结构体字段的信息, 按照(类型, tag name, FieldMatcher)缓存
+type fieldInfo struct {
+	reflect.StructField
+	key    string // 用于配对的名字, 有tag时是tag的值, 没有tag时是字段名, 经过FieldMatcher转换
+	tagged bool   // 是否设置了tag
+	skip   bool   // tag的值是"-", 或者是不能拷贝的小写字段
+	// 小写字段, 只有dst和src类型相同时才会浅拷贝
+	unexported bool
+	// 拷贝到map时使用的key, 有tag时是tag的值, 没有tag时是字段名
+	name string
+	// tag选项里设置的合并策略
+	strategy Strategy
+	// 在最外层结构体fields里的下标, 提升的字段是嵌入字段的下标
+	top int
+}
+
+type structFields struct {
+	fields []fieldInfo
+	// 嵌入结构体里提升的字段, 只用于查找
+	promoted []fieldInfo
+	// key -> fields的下标, 提升的字段排在fields后面, -1表示同名的字段有多个
+	index  map[string]int
+	opaque bool // 只有小写字段, 比如time.Time
+}
+
+type fieldsKey struct {
+	typ     reflect.Type
+	tagName string
+	matcher FieldMatcher
+}
+
+var fieldsCache sync.Map
+
+// FieldMatcher 决定dst和src的字段怎么配对
+// Key返回字段名(或者tag的值)的标准形式, 标准形式相同的两个字段会互相拷贝
+type FieldMatcher interface {
+	Key(name string) string
+}
+
+// FieldMatcherFunc 把普通函数转成FieldMatcher
+type FieldMatcherFunc func(name string) string
+
+func (fn FieldMatcherFunc) Key(name string) string {
+	return fn(name)
+}
+
+var (
+	// 字段名完全相同才配对, 默认的方式
+	MatchExact FieldMatcher = exactMatcher{}
+	// 忽略大小写, UserID和Userid可以配对
+	MatchCaseInsensitive FieldMatcher = caseInsensitiveMatcher{}
+	// 忽略大小写和下划线, 中划线, UserID, UserId, user_id可以配对
+	MatchNormalized FieldMatcher = normalizedMatcher{}
+)
+
+type exactMatcher struct{}
+
+func (exactMatcher) Key(name string) string {
+	return name
+}
+
+type caseInsensitiveMatcher struct{}
+
+func (caseInsensitiveMatcher) Key(name string) string {
+	return strings.ToLower(name)
+}
+
+type normalizedMatcher struct{}
+
+func (normalizedMatcher) Key(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, r := range name {
+		if r == '_' || r == '-' {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// 解析tag, 逗号前面是名字, 后面是选项
+func parseTag(tag string) (name string, opts string) {
+	if i := strings.IndexByte(tag, ','); i != -1 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func (f *dCopy) getMatcher() FieldMatcher {
+	if f.matcher == nil {
+		return MatchExact
+	}
+	return f.matcher
+}
+
+func (f *dCopy) getStructFields(typ reflect.Type) *structFields {
+	matcher := f.getMatcher()
+
+	key := fieldsKey{typ: typ, tagName: f.tagName, matcher: matcher}
+	// 自定义的FieldMatcher不能作为map的key时, 只在这次Do()里缓存
+	if !reflect.TypeOf(matcher).Comparable() {
+		key.matcher = nil
+		if sfs, ok := f.fields[key]; ok {
+			return sfs
+		}
+
+		sfs := newStructFields(typ, f.tagName, matcher)
+		if f.fields == nil {
+			f.fields = make(map[fieldsKey]*structFields)
+		}
+		f.fields[key] = sfs
+		return sfs
+	}
+
+	if sf, ok := fieldsCache.Load(key); ok {
+		return sf.(*structFields)
+	}
+
+	sf, _ := fieldsCache.LoadOrStore(key, newStructFields(typ, f.tagName, matcher))
+	return sf.(*structFields)
+}
+
+func newStructFields(typ reflect.Type, tagName string, matcher FieldMatcher) *structFields {
+
+	n := typ.NumField()
+	sfs := &structFields{fields: make([]fieldInfo, n), index: make(map[string]int, n), opaque: opaqueStruct(typ)}
+	for i := 0; i < n; i++ {
+		fi := newFieldInfo(typ.Field(i), tagName, matcher)
+		fi.top = i
+		sfs.fields[i] = fi
+		if fi.skip {
+			continue
+		}
+
+		// 名字重复时, 前面的字段优先
+		if _, ok := sfs.index[fi.key]; !ok {
+			sfs.index[fi.key] = i
+		}
+	}
+
+	sfs.promote(tagName, matcher)
+	return sfs
+}
+
+func newFieldInfo(sf reflect.StructField, tagName string, matcher FieldMatcher) fieldInfo {
+	fi := fieldInfo{StructField: sf}
+	fi.key = fi.Name
+	fi.unexported = fi.PkgPath != "" && !fi.Anonymous
+	fi.skip = fi.unexported
+
+	if len(tagName) > 0 {
+		if tag, ok := fi.Tag.Lookup(tagName); ok && haveTagName(tag) {
+			fi.tagged = true
+			name, opts := parseTag(tag)
+			fi.strategy = parseStrategy(opts)
+			if name == tagSkip {
+				fi.skip = true
+			} else if name != "" {
+				fi.key = name
+			}
+		}
+	} else if tag, ok := fi.Tag.Lookup(strategyTagName); ok {
+		// 没有设置tag name时不使用tag配对字段, 只读取合并策略
+		_, opts := parseTag(tag)
+		fi.strategy = parseStrategy(opts)
+	}
+
+	fi.name = fi.key
+	fi.key = matcher.Key(fi.key)
+	return fi
+}
+
+// 和reflect.Type.FieldByName一样查找嵌入结构体里的字段, 作为dst时可以和src里同名的字段配对
+// 层次浅的字段优先, 同一层有多个同名的字段时都不使用; 只查找不是指针的嵌入结构体
+// 提升的字段的Offset和Index是相对最外层结构体的
+func (s *structFields) promote(tagName string, matcher FieldMatcher) {
+	var level []*fieldInfo
+	for i := range s.fields {
+		if fi := &s.fields[i]; fi.Anonymous && !fi.skip && fi.Type.Kind() == reflect.Struct {
+			level = append(level, fi)
+		}
+	}
+
+	for len(level) > 0 {
+		var found []fieldInfo
+		count := make(map[string]int)
+		for _, embed := range level {
+			n := embed.Type.NumField()
+			for i := 0; i < n; i++ {
+				fi := newFieldInfo(embed.Type.Field(i), tagName, matcher)
+				if fi.skip {
+					continue
+				}
+
+				fi.Offset += embed.Offset
+				fi.Index = append(append([]int{}, embed.Index...), fi.Index...)
+				fi.top = embed.top
+				found = append(found, fi)
+				count[fi.key]++
+			}
+		}
+
+		start := len(s.promoted)
+		for _, fi := range found {
+			if _, ok := s.index[fi.key]; ok || count[fi.key] > 1 {
+				continue
+			}
+			s.promoted = append(s.promoted, fi)
+		}
+
+		// 同一层有多个同名字段时, 更深的同名字段也不使用
+		for key := range count {
+			if _, ok := s.index[key]; !ok {
+				s.index[key] = -1
+			}
+		}
+
+		level = level[:0]
+		for i := start; i < len(s.promoted); i++ {
+			fi := &s.promoted[i]
+			s.index[fi.key] = len(s.fields) + i
+			if fi.Anonymous && fi.Type.Kind() == reflect.Struct {
+				level = append(level, fi)
+			}
+		}
+	}
+}
+
+// 结构体里只有小写字段时, 没法逐个字段拷贝, 补丁模式下整个结构体判断是否是零值
+func opaqueStruct(typ reflect.Type) bool {
+	n := typ.NumField()
+	for i := 0; i < n; i++ {
+		if sf := typ.Field(i); sf.PkgPath == "" || sf.Anonymous {
+			return false
+		}
+	}
+	return n > 0
+}
+
+// 根据src字段的key查找dst里配对的字段, 包括嵌入结构体里提升的字段
+func (s *structFields) lookup(key string) (*fieldInfo, bool) {
+	i, ok := s.index[key]
+	if !ok || i < 0 {
+		return nil, false
+	}
+
+	if i >= len(s.fields) {
+		return &s.promoted[i-len(s.fields)], true
+	}
+	return &s.fields[i], true
+}
@@ -0,0 +1,62 @@
+//go:build go1.21
+// +build go1.21
+
+package dcopy
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// 直接使用dst和src的地址, 不需要reflect.ValueOf和指针检查
+// 泛型接口总是使用缓存, 每个(D, S)类型对只编译一次计划, Copier没有打开缓存时使用共享的缓存
+func newTypedCopy[D, S any](opts *options, dst *D, src *S) *dCopy {
+	if opts.optErr != nil {
+		return &dCopy{err: opts.optErr}
+	}
+
+	if dst == nil || src == nil {
+		return &dCopy{err: &Error{DstType: reflect.TypeOf(dst), SrcType: reflect.TypeOf(src), Err: ErrNil}}
+	}
+
+	d := &dCopy{
+		options: *opts,
+		dstType: typeOf[D](),
+		srcType: typeOf[S](),
+		dstAddr: unsafe.Pointer(dst),
+		srcAddr: unsafe.Pointer(src),
+	}
+	if d.cache == nil {
+		d.cache = sharedCache
+	}
+	return d
+}
+
+// Map 使用默认配置把src拷贝到一个新的D类型的值里, 规则和Copy一样
+// 比如 dto, err := dcopy.Map[UserDTO](user)
+// 第一次调用时编译拷贝计划, 之后相同的(D, S)直接使用, 统计信息在CacheStats()里
+func Map[D, S any](src S) (D, error) {
+	return MapWith[D](defaultCopier, src)
+}
+
+// MapWith 和Map一样, 使用c的配置
+func MapWith[D, S any](c *Copier, src S) (D, error) {
+	var dst D
+	err := newTypedCopy(&c.opts, &dst, &src).Do()
+	return dst, err
+}
+
+// CopyOf 使用默认配置把src拷贝到dst, dst和src的类型在编译时检查
+func CopyOf[D, S any](dst *D, src *S) error {
+	return newTypedCopy(&defaultCopier.opts, dst, src).Do()
+}
+
+// CloneOf 深度拷贝src, 返回和src类型相同的值
+// 和Clone一样, 小写字段是浅拷贝, 其他的指针, map, slice, interface不和src共享
+func CloneOf[T any](src T) (T, error) {
+	return Map[T](src)
+}
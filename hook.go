@@ -0,0 +1,146 @@
+package dcopy
+
+import (
+	"reflect"
+	"sync"
+)
+
+// DeepCopier 由dst的指针实现, 拷贝到这个类型时直接调用CopyFrom, 不再逐个字段拷贝
+// 适合带有不变量的类型, 比如Money, Version; src是源数据, 类型可能和dst不同
+type DeepCopier interface {
+	CopyFrom(src interface{}) error
+}
+
+// BeforeCopier 由dst的指针实现, 结构体拷贝之前调用, 返回错误时终止拷贝
+type BeforeCopier interface {
+	BeforeCopy(src interface{}) error
+}
+
+// AfterCopier 由dst的指针实现, 结构体拷贝之后调用, 可以用来填充派生字段, 返回错误时终止拷贝
+type AfterCopier interface {
+	AfterCopy(src interface{}) error
+}
+
+// HookFunc 给不能修改的类型注册的回调函数, dst是指向目标结构体的指针
+type HookFunc func(dst, src interface{}) error
+
+var (
+	deepCopierType   = reflect.TypeOf((*DeepCopier)(nil)).Elem()
+	beforeCopierType = reflect.TypeOf((*BeforeCopier)(nil)).Elem()
+	afterCopierType  = reflect.TypeOf((*AfterCopier)(nil)).Elem()
+)
+
+// 类型上实现的拷贝接口, 按类型缓存
+type typeHooks struct {
+	copyFrom bool
+	// 和kubernetes生成的代码一样, 形如 func (in *T) DeepCopyInto(out *T), -1表示没有实现
+	deepCopyInto int
+
+	before bool
+	after  bool
+}
+
+var (
+	hooksCache sync.Map
+	noHooks    = &typeHooks{deepCopyInto: -1}
+)
+
+func getTypeHooks(typ reflect.Type) *typeHooks {
+	// 匿名类型没有方法
+	if typ.Name() == "" {
+		return noHooks
+	}
+
+	if h, ok := hooksCache.Load(typ); ok {
+		return h.(*typeHooks)
+	}
+
+	ptr := reflect.PtrTo(typ)
+	h := &typeHooks{
+		copyFrom:     ptr.Implements(deepCopierType),
+		deepCopyInto: -1,
+		before:       ptr.Implements(beforeCopierType),
+		after:        ptr.Implements(afterCopierType),
+	}
+	if m, ok := ptr.MethodByName("DeepCopyInto"); ok {
+		mt := m.Type
+		if mt.NumIn() == 2 && mt.In(1) == ptr && mt.NumOut() == 0 {
+			h.deepCopyInto = m.Index
+		}
+	}
+
+	if *h == *noHooks {
+		h = noHooks
+	}
+
+	hooksCache.Store(typ, h)
+	return h
+}
+
+// dst实现了DeepCopier, 或者src实现了DeepCopyInto时, 调用类型自己的拷贝方法
+// 返回false表示没有实现, 需要继续按Kind拷贝
+func (f *dCopy) cpySelf(a *args) (bool, error) {
+	dstHooks := getTypeHooks(a.dstType)
+	if dstHooks.copyFrom {
+		dst := typePtrToValue(a.dstType, a.dstAddr).Addr().Interface().(DeepCopier)
+		if err := dst.CopyFrom(typePtrToValue(a.srcType, a.srcAddr).Interface()); err != nil {
+			return true, f.fieldError(a, err)
+		}
+		f.record(a, ActionCopied)
+		return true, nil
+	}
+
+	if a.dstType != a.srcType || dstHooks.deepCopyInto < 0 {
+		return false, nil
+	}
+
+	src := typePtrToValue(a.srcType, a.srcAddr).Addr()
+	src.Method(dstHooks.deepCopyInto).Call([]reflect.Value{typePtrToValue(a.dstType, a.dstAddr).Addr()})
+	f.record(a, ActionCopied)
+	return true, nil
+}
+
+// 返回注册回调时使用的类型, dst可以是结构体或者结构体指针
+func hookType(dst interface{}) reflect.Type {
+	typ := reflect.TypeOf(dst)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// 结构体拷贝之前, 先调用BeforeCopy, 再调用注册的回调函数
+func (f *dCopy) callBefore(a *args) error {
+	return f.callHook(a, getTypeHooks(a.dstType).before, f.beforeHooks, func(dst interface{}, src interface{}) error {
+		return dst.(BeforeCopier).BeforeCopy(src)
+	})
+}
+
+// 结构体拷贝之后, 先调用AfterCopy, 再调用注册的回调函数
+func (f *dCopy) callAfter(a *args) error {
+	return f.callHook(a, getTypeHooks(a.dstType).after, f.afterHooks, func(dst interface{}, src interface{}) error {
+		return dst.(AfterCopier).AfterCopy(src)
+	})
+}
+
+func (f *dCopy) callHook(a *args, implemented bool, hooks map[reflect.Type][]HookFunc, method HookFunc) error {
+	fns := hooks[a.dstType]
+	if !implemented && len(fns) == 0 {
+		return nil
+	}
+
+	dst := typePtrToValue(a.dstType, a.dstAddr).Addr().Interface()
+	src := typePtrToValue(a.srcType, a.srcAddr).Interface()
+	if implemented {
+		if err := method(dst, src); err != nil {
+			return f.fieldError(a, err)
+		}
+	}
+
+	for _, fn := range fns {
+		if err := fn(dst, src); err != nil {
+			return f.fieldError(a, err)
+		}
+	}
+	return nil
+}
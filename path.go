@@ -0,0 +1,71 @@
+package dcopy
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type segmentKind uint8
+
+const (
+	segNone segmentKind = iota
+	segField
+	segIndex
+	segKey
+)
+
+// 字段路径里的一段, 比如 Orders, [3], ["sku"]
+type segment struct {
+	kind  segmentKind
+	name  string
+	index int
+	key   reflect.Value
+}
+
+func (a *args) setField(name string) {
+	a.seg = segment{kind: segField, name: name}
+}
+
+func (a *args) setIndex(i int) {
+	a.seg = segment{kind: segIndex, index: i}
+}
+
+func (a *args) setKey(key reflect.Value) {
+	a.seg = segment{kind: segKey, key: key}
+}
+
+// 生成完整的字段路径, 比如 Orders[3].Items["sku"].Price
+// 只在需要的时候调用, 拷贝的热路径上不拼接字符串
+func (a *args) path() string {
+	var segs []segment
+	for p := a; p != nil; p = p.parent {
+		if p.seg.kind != segNone {
+			segs = append(segs, p.seg)
+		}
+	}
+
+	var b strings.Builder
+	for i := len(segs) - 1; i >= 0; i-- {
+		s := segs[i]
+		switch s.kind {
+		case segField:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s.name)
+		case segIndex:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(s.index))
+			b.WriteByte(']')
+		case segKey:
+			if s.key.Kind() == reflect.String {
+				fmt.Fprintf(&b, "[%q]", s.key.String())
+			} else {
+				fmt.Fprintf(&b, "[%v]", s.key.Interface())
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,349 @@
+package dcopy
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+type planOp uint8
+
+const (
+	// 交给反射拷贝, 比如DeepCopier, 结构体和map互相拷贝, 类型不兼容
+	opEngine planOp = iota
+	// 超过MaxDepth, 不拷贝
+	opSkip
+	// Kind相同的基础类型
+	opSet
+	// 不同Kind之间的转换, 比如int32 -> int64, string -> int
+	opConvert
+	// 自定义的类型转换函数
+	opConverter
+	opStruct
+	// slice和array, 长度在拷贝时才知道
+	opSlice
+	opMap
+	opPtr
+	// src是interface, 拷贝时按照里面的值的类型查找计划
+	opInterface
+	// dst是interface, src是具体的类型
+	opToInterface
+)
+
+// 编译好的拷贝计划, 每个(dst, src)类型对和配置编译一次
+// 字段配对, 转换函数和回调在编译时确定, 拷贝时只按照计划执行
+// 计划编译之后不会修改, 可以在多个goroutine里同时使用
+type plan struct {
+	op    planOp
+	dst   reflect.Type
+	src   reflect.Type
+	depth int
+
+	set       setFunc
+	convert   convertFunc
+	converter *converter
+
+	// 结构体
+	fields []fieldPlan
+	hooks  bool // dst有BeforeCopy/AfterCopy回调
+	opaque bool // 只有小写字段, 补丁模式下整体判断零值
+
+	// map的key, slice, array, 指针的元素, 以及dst是interface时src自己的计划
+	key  *plan
+	elem *plan
+
+	// 严格模式下编译时记录, 用于Precompile提前检查
+	mismatch     bool
+	unmatchedDst []string
+	unmatchedSrc []string
+}
+
+type fieldPlan struct {
+	name      string
+	dstOffset int
+	srcOffset int
+	strategy  Strategy
+
+	// 不为nil时是小写字段, 整个字段浅拷贝
+	unexported reflect.Type
+	plan       *plan
+}
+
+// 编译时记录已经编译过的类型对, 遇到递归的类型直接使用, 比如链表
+type planCompiler struct {
+	f    *dCopy
+	memo map[planKey]*plan
+}
+
+func (f *dCopy) compile(dst, src reflect.Type, depth int) *plan {
+	c := &planCompiler{f: f, memo: make(map[planKey]*plan)}
+	return c.compile(dst, src, depth)
+}
+
+// 和dCopy的判断顺序一样
+func (c *planCompiler) compile(dst, src reflect.Type, depth int) *plan {
+	f := c.f
+	key := planKey{dst: dst, src: src}
+	if f.depthLimited {
+		key.depth = depth
+	}
+
+	if p, ok := c.memo[key]; ok {
+		return p
+	}
+
+	p := &plan{dst: dst, src: src, depth: depth}
+	c.memo[key] = p
+
+	if f.overDepth(depth) {
+		p.op = opSkip
+		return p
+	}
+
+	if conv := f.getConverter(dst, src); conv != nil {
+		p.op, p.converter = opConverter, conv
+		return p
+	}
+
+	// 类型自己的拷贝方法
+	if h := getTypeHooks(dst); h.copyFrom || dst == src && h.deepCopyInto >= 0 {
+		return p
+	}
+
+	dk, sk := dst.Kind(), src.Kind()
+	switch {
+	case sk == reflect.Interface:
+		p.op = opInterface
+	case dk == reflect.Interface:
+		if src.Implements(dst) {
+			p.op, p.elem = opToInterface, c.compile(src, src, depth)
+		}
+	case sk == reflect.Slice || sk == reflect.Array:
+		if dk == reflect.Array && dst.Len() == 0 {
+			p.op = opSkip
+		} else if (dk == reflect.Slice || dk == reflect.Array) && f.canConvert(dst.Elem(), src.Elem()) {
+			p.op, p.elem = opSlice, c.compile(dst.Elem(), src.Elem(), depth)
+		}
+	case sk == reflect.Map:
+		if dk == reflect.Map && f.canConvert(dst.Key(), src.Key()) && f.canConvert(dst.Elem(), src.Elem()) {
+			p.op = opMap
+			p.key = c.compile(dst.Key(), src.Key(), depth)
+			p.elem = c.compile(dst.Elem(), src.Elem(), depth)
+		}
+	case sk == reflect.Struct:
+		if dk == reflect.Struct {
+			c.compileStruct(p)
+		}
+	case sk == reflect.Ptr:
+		if dk == reflect.Ptr {
+			p.op, p.elem = opPtr, c.compile(dst.Elem(), src.Elem(), depth)
+		}
+	case dk == sk:
+		if set := getSetFunc(sk); set != nil {
+			p.op, p.set = opSet, set
+		}
+	default:
+		if convert := f.getConvertFunc(dk, sk); convert != nil {
+			p.op, p.convert = opConvert, convert
+		}
+	}
+
+	// 除了结构体和map互相拷贝, 交给反射拷贝的都是类型不兼容
+	structMap := sk == reflect.Map && dk == reflect.Struct || sk == reflect.Struct && dk == reflect.Map || mapToStructPtr(dst, src)
+	p.mismatch = p.op == opEngine && !structMap
+	return p
+}
+
+// 和cpyStruct一样的字段配对规则
+func (c *planCompiler) compileStruct(p *plan) {
+	f := c.f
+	dst, src := p.dst, p.src
+
+	dstHooks := getTypeHooks(dst)
+	p.op = opStruct
+	p.hooks = dstHooks.before || dstHooks.after || len(f.beforeHooks[dst]) > 0 || len(f.afterHooks[dst]) > 0
+
+	srcFields := f.getStructFields(src)
+	dstFields := f.getStructFields(dst)
+	p.opaque = srcFields.opaque
+
+	var matched []bool
+	if f.strict {
+		matched = make([]bool, len(dstFields.fields))
+	}
+
+	for i := range srcFields.fields {
+		sf := &srcFields.fields[i]
+		if sf.unexported {
+			if dst == src {
+				p.fields = append(p.fields, fieldPlan{
+					name:       sf.Name,
+					dstOffset:  int(sf.Offset),
+					srcOffset:  int(sf.Offset),
+					unexported: sf.Type,
+				})
+			}
+			continue
+		}
+
+		if sf.skip || len(f.tagName) > 0 && !sf.tagged {
+			continue
+		}
+
+		dstSf, ok := dstFields.lookup(sf.key)
+		if !ok {
+			if f.strict {
+				p.unmatchedSrc = append(p.unmatchedSrc, sf.Name)
+			}
+			continue
+		}
+
+		if matched != nil {
+			matched[dstSf.top] = true
+		}
+
+		strategy := dstSf.strategy
+		if strategy == StrategyDefault {
+			strategy = sf.strategy
+		}
+
+		p.fields = append(p.fields, fieldPlan{
+			name:      sf.Name,
+			dstOffset: int(dstSf.Offset),
+			srcOffset: int(sf.Offset),
+			strategy:  strategy,
+			plan:      c.compile(dstSf.Type, sf.Type, p.depth+1),
+		})
+	}
+
+	// 和unmatchedDst的规则一样
+	for i := range matched {
+		df := &dstFields.fields[i]
+		if matched[i] || df.skip || len(f.tagName) > 0 && !df.tagged {
+			continue
+		}
+		p.unmatchedDst = append(p.unmatchedDst, df.Name)
+	}
+}
+
+// p不为nil时按照计划拷贝, 否则使用反射拷贝
+func (f *dCopy) next(p *plan, a *args, depth int) error {
+	if p != nil {
+		return f.runPlan(p, a)
+	}
+	return f.dCopy(a, depth)
+}
+
+func (f *dCopy) runPlan(p *plan, a *args) error {
+	if p.op == opEngine {
+		return f.dCopy(a, p.depth)
+	}
+
+	if f.ignoreZero && isZero(p.src, a.srcAddr) {
+		return nil
+	}
+
+	return f.execPlan(p, a)
+}
+
+func (f *dCopy) execPlan(p *plan, a *args) error {
+	switch p.op {
+	case opSkip:
+		return nil
+	case opSet:
+		p.set(a.dstAddr, a.srcAddr)
+		return nil
+	case opConvert:
+		if err := p.convert(a.dstAddr, a.srcAddr, f.overflow); err != nil {
+			return f.fieldError(a, err)
+		}
+		return nil
+	case opConverter:
+		return f.cpyConverter(a, p.converter)
+	case opStruct:
+		return f.runStruct(p, a)
+	case opSlice:
+		return f.cpySliceArray(a, p.depth, p.elem)
+	case opMap:
+		return f.cpyMap(a, p.depth, p.key, p.elem)
+	case opPtr:
+		return f.cpyPtr(a, p.depth, p.elem)
+	case opInterface:
+		return f.runInterface(p, a)
+	case opToInterface:
+		return f.cpyToInterface(a, p.depth, p.elem)
+	}
+
+	return f.dCopy(a, p.depth)
+}
+
+func (f *dCopy) runStruct(p *plan, a *args) error {
+	if p.hooks {
+		if err := f.callBefore(a); err != nil {
+			return err
+		}
+	}
+
+	for i := range p.fields {
+		fp := &p.fields[i]
+		dstAddr := add(a.dstAddr, fp.dstOffset)
+		srcAddr := add(a.srcAddr, fp.srcOffset)
+
+		if fp.unexported != nil {
+			if f.skipUnexported(p.opaque, fp.unexported, srcAddr) {
+				continue
+			}
+			typePtrToValue(fp.unexported, dstAddr).Set(typePtrToValue(fp.unexported, srcAddr))
+			continue
+		}
+
+		// 基础类型不会出错, 不需要记录字段路径
+		if fp.plan.op == opSet {
+			if !f.ignoreZero || !isZero(fp.plan.src, srcAddr) {
+				fp.plan.set(dstAddr, srcAddr)
+			}
+			continue
+		}
+
+		if err := f.runField(fp, a, dstAddr, srcAddr); err != nil {
+			return err
+		}
+	}
+
+	if p.hooks {
+		return f.callAfter(a)
+	}
+	return nil
+}
+
+func (f *dCopy) runField(fp *fieldPlan, a *args, dstAddr, srcAddr unsafe.Pointer) error {
+	arg := newArgs(a, fp.plan.dst, fp.plan.src, dstAddr, srcAddr)
+	defer argsPool.Put(arg)
+
+	arg.setField(fp.name)
+	arg.strategy = fp.strategy
+	return f.runPlan(fp.plan, arg)
+}
+
+// 和cpyInterface一样, interface里的值的类型每次都可能不同, 拷贝时再查找计划
+func (f *dCopy) runInterface(p *plan, a *args) error {
+	srcVal := typePtrToValue(p.src, a.srcAddr).Elem()
+	if !srcVal.IsValid() {
+		if p.dst.Kind() == reflect.Interface {
+			typePtrToValue(p.dst, a.dstAddr).Set(reflect.Zero(p.dst))
+		}
+		return nil
+	}
+
+	tmp := reflect.New(srcVal.Type()).Elem()
+	tmp.Set(srcVal)
+
+	arg := newArgs(a, p.dst, srcVal.Type(), a.dstAddr, unsafe.Pointer(tmp.UnsafeAddr()))
+	defer argsPool.Put(arg)
+
+	next := f.getPlan(p.dst, srcVal.Type(), p.depth)
+	if p.dst.Kind() == reflect.Interface {
+		// 和cpyToInterface一样, 不检查interface里的值是不是零值
+		return f.execPlan(next, arg)
+	}
+	return f.runPlan(next, arg)
+}
@@ -0,0 +1,102 @@
+package dcopy
+
+import "reflect"
+
+// Precompile 使用默认配置编译dstType和srcType的拷贝计划, 保存到dCopy.Cache()使用的共享缓存里
+// dstType和srcType和Copy(&dst, &src)里dst, src指向的类型一样, 比如
+//
+//	dcopy.Precompile(reflect.TypeOf(UserDTO{}), reflect.TypeOf(User{}))
+func Precompile(dstType, srcType reflect.Type) error {
+	return defaultCopier.with(func(o *options) { o.cache = sharedCache }).Precompile(dstType, srcType)
+}
+
+// PrecompileValue 和Precompile一样, dst和src是示例值, 比如PrecompileValue(UserDTO{}, User{})
+func PrecompileValue(dst, src interface{}) error {
+	return Precompile(reflect.TypeOf(dst), reflect.TypeOf(src))
+}
+
+// Precompile 在服务启动时编译拷贝计划, 避免第一次拷贝时编译
+// 只有打开了缓存并且不是严格模式的Copier, 计划才会保存到c的缓存里, 不计入CacheStats的命中和未命中
+// 没有打开缓存时只编译一次, 可以用来检查配置; 严格模式拷贝时不使用缓存, 只检查类型的映射, 有字段没法拷贝时返回*StrictError
+// 严格模式的检查只看类型, interface里的值和结构体与map之间的拷贝只能在拷贝时检查
+// 路径里slice, array和map的元素写成[], 比如 Items[].Price
+func (c *Copier) Precompile(dstType, srcType reflect.Type) error {
+	if dstType == nil || srcType == nil {
+		return &Error{DstType: dstType, SrcType: srcType, Err: ErrNil}
+	}
+
+	if c.opts.optErr != nil {
+		return c.opts.optErr
+	}
+
+	f := &dCopy{options: c.opts}
+
+	var p *plan
+	if f.usePlan() {
+		p = f.warmPlan(dstType, srcType)
+	} else {
+		p = f.compile(dstType, srcType, 0)
+	}
+
+	if !f.strict {
+		return nil
+	}
+
+	e := &StrictError{}
+	checkPlan(p, "", e, make(map[*plan]bool))
+	if e.empty() {
+		return nil
+	}
+	return e
+}
+
+// PrecompileValue 和Precompile一样, dst和src是示例值
+func (c *Copier) PrecompileValue(dst, src interface{}) error {
+	return c.Precompile(reflect.TypeOf(dst), reflect.TypeOf(src))
+}
+
+// 遍历计划, 收集严格模式下没法拷贝的字段
+func checkPlan(p *plan, path string, e *StrictError, seen map[*plan]bool) {
+	// 递归的类型, 比如链表
+	if seen[p] {
+		return
+	}
+	seen[p] = true
+	defer delete(seen, p)
+
+	if p.mismatch {
+		e.Mismatched = append(e.Mismatched, Mismatch{Path: path, DstType: p.dst, SrcType: p.src})
+		return
+	}
+
+	switch p.op {
+	case opStruct:
+		for _, name := range p.unmatchedDst {
+			e.UnmatchedDst = append(e.UnmatchedDst, fieldPath(path, name))
+		}
+
+		for _, name := range p.unmatchedSrc {
+			e.UnmatchedSrc = append(e.UnmatchedSrc, fieldPath(path, name))
+		}
+
+		for i := range p.fields {
+			if fp := &p.fields[i]; fp.plan != nil {
+				checkPlan(fp.plan, fieldPath(path, fp.name), e, seen)
+			}
+		}
+	case opSlice:
+		checkPlan(p.elem, path+"[]", e, seen)
+	case opMap:
+		checkPlan(p.key, path+"[]", e, seen)
+		checkPlan(p.elem, path+"[]", e, seen)
+	case opPtr, opToInterface:
+		checkPlan(p.elem, path, e, seen)
+	}
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,46 @@
+package dcopy
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// 记录一次Do()里已经拷贝过的指针, map, slice
+// 用于处理循环引用和共享引用, src里指向同一个对象的成员, 拷贝到dst之后也指向同一个新对象
+type refKey struct {
+	srcAddr unsafe.Pointer
+	srcLen  int // slice长度不同，不是同一个对象
+	dstType reflect.Type
+	srcType reflect.Type
+}
+
+type refVal struct {
+	addr unsafe.Pointer
+	len  int
+	cap  int
+}
+
+func newRefKey(a *args, srcAddr unsafe.Pointer, srcLen int) refKey {
+	return refKey{srcAddr: srcAddr, srcLen: srcLen, dstType: a.dstType, srcType: a.srcType}
+}
+
+func (f *dCopy) loadRef(key refKey) (refVal, bool) {
+	if f.noTrackRef || f.visited == nil {
+		return refVal{}, false
+	}
+
+	v, ok := f.visited[key]
+	return v, ok
+}
+
+func (f *dCopy) saveRef(key refKey, val refVal) {
+	if f.noTrackRef {
+		return
+	}
+
+	if f.visited == nil {
+		f.visited = make(map[refKey]refVal, 8)
+	}
+
+	f.visited[key] = val
+}
@@ -0,0 +1,113 @@
+package dcopy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Action 拷贝时对一个字段做的处理
+type Action int
+
+const (
+	// 直接拷贝
+	ActionCopied Action = iota
+	// 经过类型转换之后拷贝, 包括数值, 字符串转换和自定义的转换函数
+	ActionConverted
+	// 被tag跳过, tag是"-"或者设置了tag name但是字段没有tag
+	ActionSkippedByTag
+	// 超过MaxDepth设置的深度
+	ActionSkippedByDepth
+	// IgnoreZero模式下, src是零值
+	ActionSkippedZero
+	// dst和src的类型不兼容
+	ActionKindMismatch
+	// src是nil的指针, map, slice或者interface
+	ActionNilSource
+	// src的字段在dst里没有配对的字段
+	ActionUnmatched
+)
+
+var actionNames = map[Action]string{
+	ActionCopied:         "copied",
+	ActionConverted:      "converted",
+	ActionSkippedByTag:   "skipped-by-tag",
+	ActionSkippedByDepth: "skipped-by-depth",
+	ActionSkippedZero:    "skipped-zero",
+	ActionKindMismatch:   "kind-mismatch",
+	ActionNilSource:      "nil-source",
+	ActionUnmatched:      "unmatched",
+}
+
+func (a Action) String() string {
+	if name, ok := actionNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// ReportEntry 报告里的一条记录
+type ReportEntry struct {
+	Path    string
+	Action  Action
+	DstType reflect.Type // src字段没有配对时是nil
+	SrcType reflect.Type
+}
+
+func (e ReportEntry) String() string {
+	path := e.Path
+	if path == "" {
+		path = "."
+	}
+	return fmt.Sprintf("%s: %s (%v <- %v)", path, e.Action, e.DstType, e.SrcType)
+}
+
+// Report DoWithReport返回的报告, 按访问的顺序记录每个字段做了什么处理
+type Report struct {
+	Entries []ReportEntry
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Filter 返回指定处理方式的记录
+func (r *Report) Filter(action Action) []ReportEntry {
+	var entries []ReportEntry
+	for _, e := range r.Entries {
+		if e.Action == action {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// DoWithReport 和Do()一样拷贝数据, 同时返回每个字段的处理报告, 用于调试字段的配对
+// 出错时也会返回出错之前的报告
+func (f *dCopy) DoWithReport() (*Report, error) {
+	f.report = &Report{}
+	defer func() { f.report = nil }()
+
+	report := f.report
+	err := f.Do()
+	return report, err
+}
+
+func (f *dCopy) record(a *args, action Action) {
+	if f.report != nil {
+		f.report.Entries = append(f.report.Entries, ReportEntry{Path: a.path(), Action: action, DstType: a.dstType, SrcType: a.srcType})
+	}
+}
+
+// 记录没有进入递归的字段
+func (f *dCopy) recordField(a *args, sf *fieldInfo, dst reflect.Type, action Action) {
+	if f.report != nil {
+		path := childPath(a, segment{kind: segField, name: sf.Name})
+		f.report.Entries = append(f.report.Entries, ReportEntry{Path: path, Action: action, DstType: dst, SrcType: sf.Type})
+	}
+}
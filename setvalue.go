@@ -1,7 +1,11 @@
 package dcopy
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
 	"unsafe"
 )
 
@@ -95,3 +99,334 @@ func setComplex64(dstAddr, srcAddr unsafe.Pointer) {
 func setComplex128(dstAddr, srcAddr unsafe.Pointer) {
 	*(*complex128)(dstAddr) = *(*complex128)(srcAddr)
 }
+
+// 数值类型的分类
+type numClass uint8
+
+const (
+	numInt numClass = iota + 1
+	numUint
+	numFloat
+)
+
+// 数值类型转换时的中间表示
+type number struct {
+	class numClass
+	i     int64
+	u     uint64
+	f     float64
+}
+
+func (n number) String() string {
+	switch n.class {
+	case numInt:
+		return strconv.FormatInt(n.i, 10)
+	case numUint:
+		return strconv.FormatUint(n.u, 10)
+	}
+	return strconv.FormatFloat(n.f, 'g', -1, 64)
+}
+
+type getNumFunc func(addr unsafe.Pointer) number
+
+// 写入数值, 返回false表示溢出
+type setNumFunc func(addr unsafe.Pointer, n number, mode OverflowMode) bool
+
+type convertFunc func(dstAddr, srcAddr unsafe.Pointer, mode OverflowMode) error
+
+type kindPair struct {
+	dst reflect.Kind
+	src reflect.Kind
+}
+
+var getNumTab = map[reflect.Kind]getNumFunc{
+	reflect.Int:     func(p unsafe.Pointer) number { return number{class: numInt, i: int64(*(*int)(p))} },
+	reflect.Int8:    func(p unsafe.Pointer) number { return number{class: numInt, i: int64(*(*int8)(p))} },
+	reflect.Int16:   func(p unsafe.Pointer) number { return number{class: numInt, i: int64(*(*int16)(p))} },
+	reflect.Int32:   func(p unsafe.Pointer) number { return number{class: numInt, i: int64(*(*int32)(p))} },
+	reflect.Int64:   func(p unsafe.Pointer) number { return number{class: numInt, i: *(*int64)(p)} },
+	reflect.Uint:    func(p unsafe.Pointer) number { return number{class: numUint, u: uint64(*(*uint)(p))} },
+	reflect.Uint8:   func(p unsafe.Pointer) number { return number{class: numUint, u: uint64(*(*uint8)(p))} },
+	reflect.Uint16:  func(p unsafe.Pointer) number { return number{class: numUint, u: uint64(*(*uint16)(p))} },
+	reflect.Uint32:  func(p unsafe.Pointer) number { return number{class: numUint, u: uint64(*(*uint32)(p))} },
+	reflect.Uint64:  func(p unsafe.Pointer) number { return number{class: numUint, u: *(*uint64)(p)} },
+	reflect.Float32: func(p unsafe.Pointer) number { return number{class: numFloat, f: float64(*(*float32)(p))} },
+	reflect.Float64: func(p unsafe.Pointer) number { return number{class: numFloat, f: *(*float64)(p)} },
+}
+
+var setNumTab = map[reflect.Kind]setNumFunc{
+	reflect.Int: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toInt(n, strconv.IntSize, mode)
+		*(*int)(p) = int(v)
+		return ok
+	},
+	reflect.Int8: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toInt(n, 8, mode)
+		*(*int8)(p) = int8(v)
+		return ok
+	},
+	reflect.Int16: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toInt(n, 16, mode)
+		*(*int16)(p) = int16(v)
+		return ok
+	},
+	reflect.Int32: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toInt(n, 32, mode)
+		*(*int32)(p) = int32(v)
+		return ok
+	},
+	reflect.Int64: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toInt(n, 64, mode)
+		*(*int64)(p) = v
+		return ok
+	},
+	reflect.Uint: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toUint(n, strconv.IntSize, mode)
+		*(*uint)(p) = uint(v)
+		return ok
+	},
+	reflect.Uint8: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toUint(n, 8, mode)
+		*(*uint8)(p) = uint8(v)
+		return ok
+	},
+	reflect.Uint16: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toUint(n, 16, mode)
+		*(*uint16)(p) = uint16(v)
+		return ok
+	},
+	reflect.Uint32: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toUint(n, 32, mode)
+		*(*uint32)(p) = uint32(v)
+		return ok
+	},
+	reflect.Uint64: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toUint(n, 64, mode)
+		*(*uint64)(p) = v
+		return ok
+	},
+	reflect.Float32: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toFloat(n, 32, mode)
+		*(*float32)(p) = float32(v)
+		return ok
+	},
+	reflect.Float64: func(p unsafe.Pointer, n number, mode OverflowMode) bool {
+		v, ok := toFloat(n, 64, mode)
+		*(*float64)(p) = v
+		return ok
+	},
+}
+
+// 不同数值类型之间的转换表, 和copyTab一样按Kind查找
+var convertTab = make(map[kindPair]convertFunc)
+
+func init() {
+	for dk, set := range setNumTab {
+		for sk, get := range getNumTab {
+			if dk == sk {
+				continue
+			}
+
+			convertTab[kindPair{dst: dk, src: sk}] = newNumConvert(dk, set, get)
+		}
+	}
+}
+
+func newNumConvert(dstKind reflect.Kind, set setNumFunc, get getNumFunc) convertFunc {
+	return func(dstAddr, srcAddr unsafe.Pointer, mode OverflowMode) error {
+		n := get(srcAddr)
+		if !set(dstAddr, n, mode) && mode == OverflowError {
+			return fmt.Errorf("%w: %s overflows %s", ErrOverflow, n, dstKind)
+		}
+		return nil
+	}
+}
+
+func getConvertFunc(dst, src reflect.Kind) convertFunc {
+	return convertTab[kindPair{dst: dst, src: src}]
+}
+
+// 转成bits位的有符号整数, 第二个返回值为false表示溢出
+func toInt(n number, bits int, mode OverflowMode) (int64, bool) {
+	max := int64(1)<<(bits-1) - 1
+	min := -max - 1
+
+	switch n.class {
+	case numInt:
+		if n.i < min {
+			return saturateInt(n.i, min, mode), false
+		}
+		if n.i > max {
+			return saturateInt(n.i, max, mode), false
+		}
+		return n.i, true
+	case numUint:
+		if n.u > uint64(max) {
+			return saturateInt(int64(n.u), max, mode), false
+		}
+		return int64(n.u), true
+	}
+
+	switch {
+	case math.IsNaN(n.f):
+		return 0, false
+	case n.f < float64(min):
+		return saturateInt(int64(n.f), min, mode), false
+	case n.f >= -float64(min):
+		return saturateInt(int64(n.f), max, mode), false
+	}
+	return int64(n.f), true
+}
+
+// 转成bits位的无符号整数, 第二个返回值为false表示溢出
+func toUint(n number, bits int, mode OverflowMode) (uint64, bool) {
+	max := uint64(math.MaxUint64) >> (64 - bits)
+
+	switch n.class {
+	case numInt:
+		if n.i < 0 {
+			return saturateUint(uint64(n.i), 0, mode), false
+		}
+		if uint64(n.i) > max {
+			return saturateUint(uint64(n.i), max, mode), false
+		}
+		return uint64(n.i), true
+	case numUint:
+		if n.u > max {
+			return saturateUint(n.u, max, mode), false
+		}
+		return n.u, true
+	}
+
+	switch {
+	case math.IsNaN(n.f):
+		return 0, false
+	case n.f < 0:
+		return saturateUint(uint64(int64(n.f)), 0, mode), false
+	case n.f >= float64(max)+1:
+		return saturateUint(uint64(n.f), max, mode), false
+	}
+	return uint64(n.f), true
+}
+
+// 转成浮点数, 整数转浮点数只会丢失精度, 不算溢出
+func toFloat(n number, bits int, mode OverflowMode) (float64, bool) {
+	switch n.class {
+	case numInt:
+		return float64(n.i), true
+	case numUint:
+		return float64(n.u), true
+	}
+
+	if bits == 32 && !math.IsInf(n.f, 0) && math.Abs(n.f) > math.MaxFloat32 {
+		if mode == OverflowSaturate {
+			return math.Copysign(math.MaxFloat32, n.f), false
+		}
+		return n.f, false
+	}
+	return n.f, true
+}
+
+func saturateInt(truncate, limit int64, mode OverflowMode) int64 {
+	if mode == OverflowSaturate {
+		return limit
+	}
+	return truncate
+}
+
+func saturateUint(truncate, limit uint64, mode OverflowMode) uint64 {
+	if mode == OverflowSaturate {
+		return limit
+	}
+	return truncate
+}
+
+// 字符串和数值, bool之间的转换表, 需要打开ConvertString才会使用
+var stringConvertTab = make(map[kindPair]convertFunc)
+
+func init() {
+	for k, set := range setNumTab {
+		stringConvertTab[kindPair{dst: k, src: reflect.String}] = newParseNum(k, set)
+	}
+
+	for k, get := range getNumTab {
+		stringConvertTab[kindPair{dst: reflect.String, src: k}] = newFormatNum(k, get)
+	}
+
+	stringConvertTab[kindPair{dst: reflect.Bool, src: reflect.String}] = parseBool
+	stringConvertTab[kindPair{dst: reflect.String, src: reflect.Bool}] = formatBool
+}
+
+func getStringConvertFunc(dst, src reflect.Kind) convertFunc {
+	return stringConvertTab[kindPair{dst: dst, src: src}]
+}
+
+// 字符串解析成数值, 空字符串转成零值
+func newParseNum(dstKind reflect.Kind, set setNumFunc) convertFunc {
+	return func(dstAddr, srcAddr unsafe.Pointer, mode OverflowMode) (err error) {
+		s := strings.TrimSpace(*(*string)(srcAddr))
+
+		var n number
+		switch dstKind {
+		case reflect.Float32, reflect.Float64:
+			n.class = numFloat
+			if s != "" {
+				n.f, err = strconv.ParseFloat(s, 64)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n.class = numUint
+			if s != "" {
+				n.u, err = strconv.ParseUint(s, 10, 64)
+			}
+		default:
+			n.class = numInt
+			if s != "" {
+				n.i, err = strconv.ParseInt(s, 10, 64)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !set(dstAddr, n, mode) && mode == OverflowError {
+			return fmt.Errorf("%w: %s overflows %s", ErrOverflow, n, dstKind)
+		}
+		return nil
+	}
+}
+
+func newFormatNum(srcKind reflect.Kind, get getNumFunc) convertFunc {
+	return func(dstAddr, srcAddr unsafe.Pointer, mode OverflowMode) error {
+		n := get(srcAddr)
+		if srcKind == reflect.Float32 {
+			*(*string)(dstAddr) = strconv.FormatFloat(n.f, 'g', -1, 32)
+			return nil
+		}
+
+		*(*string)(dstAddr) = n.String()
+		return nil
+	}
+}
+
+// 字符串解析成bool, 空字符串转成false
+func parseBool(dstAddr, srcAddr unsafe.Pointer, mode OverflowMode) error {
+	s := strings.TrimSpace(*(*string)(srcAddr))
+	if s == "" {
+		*(*bool)(dstAddr) = false
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+
+	*(*bool)(dstAddr) = b
+	return nil
+}
+
+func formatBool(dstAddr, srcAddr unsafe.Pointer, mode OverflowMode) error {
+	*(*string)(dstAddr) = strconv.FormatBool(*(*bool)(srcAddr))
+	return nil
+}
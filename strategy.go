@@ -0,0 +1,111 @@
+package dcopy
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// Strategy 拷贝map和slice时, 怎么处理dst里已有的数据
+type Strategy int
+
+const (
+	// 默认策略: map合并到dst已有的map里, key冲突时使用src的值; slice使用新的容器
+	StrategyDefault Strategy = iota
+	// 使用新的容器, 丢弃dst原来的数据
+	StrategyReplace
+	// map保留dst已有的key, key冲突时使用src的值; slice追加到dst的后面
+	StrategyMerge
+	// map保留dst已有的key, key冲突时保留dst的值; slice追加到dst的后面
+	StrategyMergeKeep
+	// 按元素覆盖: slice的第i个元素拷贝到dst的第i个元素上, dst长度不够时扩展;
+	// map的key冲突时, src的值递归拷贝到dst原来的值上
+	StrategyOverlay
+)
+
+// 没有调用RegisterTagName时, 从这个tag里读取字段的合并策略, 比如`copy:",append"`
+const strategyTagName = "copy"
+
+// tag里的选项和策略的对应关系, 比如`copy:",append"`
+var strategyTags = map[string]Strategy{
+	"replace": StrategyReplace,
+	"merge":   StrategyMerge,
+	"append":  StrategyMerge,
+	"keep":    StrategyMergeKeep,
+	"overlay": StrategyOverlay,
+}
+
+func parseStrategy(opts string) Strategy {
+	for opts != "" {
+		var opt string
+		opt, opts = parseTag(opts)
+		if s, ok := strategyTags[strings.TrimSpace(opt)]; ok {
+			return s
+		}
+	}
+	return StrategyDefault
+}
+
+// 字段上设置的策略优先, 其次是Strategy()设置的策略
+func (f *dCopy) getStrategy(a *args) Strategy {
+	if a.strategy != StrategyDefault {
+		return a.strategy
+	}
+	return f.strategy
+}
+
+// 按照策略准备dst的slice, 返回src的第一个元素拷贝到dst的位置
+// done为true表示已经处理完, 不需要再拷贝元素
+func (f *dCopy) prepareSlice(a *args, srcHeader, dstHeader *sliceHeader) (start int, done bool) {
+	dst := a.dstType
+
+	switch f.getStrategy(a) {
+	case StrategyMerge, StrategyMergeKeep:
+		if srcHeader.Len == 0 {
+			return 0, true
+		}
+
+		// 总是分配新的内存, 不改写dst原来的底层数组
+		start = dstHeader.Len
+		growSlice(dst, dstHeader, dstHeader.Len+srcHeader.Len)
+		return start, false
+	case StrategyOverlay:
+		if srcHeader.Len > dstHeader.Len {
+			growSlice(dst, dstHeader, srcHeader.Len)
+		}
+		return 0, false
+	}
+
+	if srcHeader.Data == nil {
+		*dstHeader = sliceHeader{}
+		f.record(a, ActionNilSource)
+		return 0, true
+	}
+
+	var key refKey
+	if a.srcType.Kind() == reflect.Slice {
+		key = newRefKey(a, srcHeader.Data, srcHeader.Len)
+		if ref, ok := f.loadRef(key); ok {
+			*dstHeader = sliceHeader{Data: ref.addr, Len: ref.len, Cap: ref.cap}
+			return 0, true
+		}
+	}
+
+	// 使用dst的类型分配内存
+	newSlice := reflect.MakeSlice(dst, srcHeader.Len, srcHeader.Len)
+	*dstHeader = sliceHeader{Data: unsafe.Pointer(newSlice.Pointer()), Len: srcHeader.Len, Cap: srcHeader.Len}
+	if key.srcAddr != nil {
+		f.saveRef(key, refVal{addr: dstHeader.Data, len: dstHeader.Len, cap: dstHeader.Cap})
+	}
+	return 0, false
+}
+
+// 分配长度是n的新slice, 保留dst原来的元素
+func growSlice(typ reflect.Type, header *sliceHeader, n int) {
+	newSlice := reflect.MakeSlice(typ, n, n)
+	if header.Len > 0 {
+		reflect.Copy(newSlice, typePtrToValue(typ, unsafe.Pointer(header)))
+	}
+
+	*header = sliceHeader{Data: unsafe.Pointer(newSlice.Pointer()), Len: n, Cap: n}
+}
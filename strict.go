@@ -0,0 +1,124 @@
+package dcopy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Mismatch dst和src的类型不兼容, 没有拷贝的字段
+type Mismatch struct {
+	Path    string
+	DstType reflect.Type
+	SrcType reflect.Type
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s(%s <- %s)", m.Path, m.DstType, m.SrcType)
+}
+
+// StrictError Strict模式下, 有字段没有拷贝时Do()返回的错误
+// 路径的格式和错误信息里的一样, 比如 Orders[3].Items["sku"].Price
+type StrictError struct {
+	UnmatchedDst []string   // dst里没有对应src字段的字段
+	UnmatchedSrc []string   // src里没有对应dst字段的字段
+	Mismatched   []Mismatch // 类型不兼容的字段
+}
+
+func (e *StrictError) Error() string {
+	var b strings.Builder
+	b.WriteString("dcopy: strict mode")
+	if len(e.UnmatchedDst) > 0 {
+		fmt.Fprintf(&b, ", unmatched dst fields: %s", strings.Join(e.UnmatchedDst, ", "))
+	}
+
+	if len(e.UnmatchedSrc) > 0 {
+		fmt.Fprintf(&b, ", unmatched src fields: %s", strings.Join(e.UnmatchedSrc, ", "))
+	}
+
+	if len(e.Mismatched) > 0 {
+		b.WriteString(", mismatched types: ")
+		for i, m := range e.Mismatched {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(m.String())
+		}
+	}
+	return b.String()
+}
+
+func (e *StrictError) empty() bool {
+	return len(e.UnmatchedDst) == 0 && len(e.UnmatchedSrc) == 0 && len(e.Mismatched) == 0
+}
+
+func (f *dCopy) getStrictError() *StrictError {
+	if f.strictErr == nil {
+		f.strictErr = &StrictError{}
+	}
+	return f.strictErr
+}
+
+// 子节点的路径, 只在出错或者Strict模式下调用
+func childPath(a *args, seg segment) string {
+	child := newArgs(a, nil, nil, nil, nil)
+	defer argsPool.Put(child)
+
+	child.seg = seg
+	return child.path()
+}
+
+// 记录类型不兼容没有拷贝的字段
+func (f *dCopy) mismatch(a *args) error {
+	f.record(a, ActionKindMismatch)
+	if f.strict {
+		e := f.getStrictError()
+		e.Mismatched = append(e.Mismatched, Mismatch{Path: a.path(), DstType: a.dstType, SrcType: a.srcType})
+	}
+	return nil
+}
+
+// 记录src里没有配对的字段
+func (f *dCopy) unmatchedSrc(a *args, seg segment, src reflect.Type) {
+	if !f.strict && f.report == nil {
+		return
+	}
+
+	path := childPath(a, seg)
+	if f.report != nil {
+		f.report.Entries = append(f.report.Entries, ReportEntry{Path: path, Action: ActionUnmatched, SrcType: src})
+	}
+
+	if f.strict {
+		e := f.getStrictError()
+		e.UnmatchedSrc = append(e.UnmatchedSrc, path)
+	}
+}
+
+// 记录dst里没有配对的字段, matched是已经拷贝过的dst字段
+func (f *dCopy) unmatchedDst(a *args, dstFields *structFields, matched []bool) {
+	if !f.strict {
+		return
+	}
+
+	for i := range dstFields.fields {
+		df := &dstFields.fields[i]
+		if matched[i] || df.skip || len(f.tagName) > 0 && !df.tagged {
+			continue
+		}
+
+		e := f.getStrictError()
+		e.UnmatchedDst = append(e.UnmatchedDst, childPath(a, segment{kind: segField, name: df.Name}))
+	}
+}
+
+// 结构体拷贝到map时, 字段的类型和map的value不兼容
+func (f *dCopy) mismatchField(a *args, sf *fieldInfo, dst reflect.Type) {
+	f.recordField(a, sf, dst, ActionKindMismatch)
+	if !f.strict {
+		return
+	}
+
+	e := f.getStrictError()
+	e.Mismatched = append(e.Mismatched, Mismatch{Path: childPath(a, segment{kind: segField, name: sf.Name}), DstType: dst, SrcType: sf.Type})
+}
@@ -0,0 +1,153 @@
+package dcopy
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// 结构体拷贝到map[string]T, key是字段名或者tag的值
+func (f *dCopy) cpyStructToMap(a *args, depth int) error {
+	dst := a.dstType
+	src := a.srcType
+
+	if dst.Key().Kind() != reflect.String {
+		return f.mismatch(a)
+	}
+
+	dstVal := typePtrToValue(dst, a.dstAddr)
+	srcFields := f.getStructFields(src)
+	if dstVal.IsNil() {
+		dstVal.Set(reflect.MakeMapWithSize(dst, len(srcFields.fields)))
+	}
+
+	newVal := reflect.New(dst.Elem()).Elem()
+	zeroVal := reflect.Zero(dst.Elem())
+	for i := range srcFields.fields {
+		sf := &srcFields.fields[i]
+		if sf.unexported {
+			continue
+		}
+
+		// tag是"-", 或者设置了tag name, 只拷贝有tag的字段
+		if sf.skip || len(f.tagName) > 0 && !sf.tagged {
+			f.recordField(a, sf, nil, ActionSkippedByTag)
+			continue
+		}
+
+		if !f.canConvert(dst.Elem(), sf.Type) {
+			f.mismatchField(a, sf, dst.Elem())
+			continue
+		}
+
+		// 补丁模式下零值的字段不写到map里, 保留map里原来的值
+		srcFieldAddr := add(a.srcAddr, int(sf.Offset))
+		if f.ignoreZero && isZero(sf.Type, srcFieldAddr) {
+			f.recordField(a, sf, dst.Elem(), ActionSkippedZero)
+			continue
+		}
+
+		newVal.Set(zeroVal)
+		err := func() error {
+			arg := newArgs(a, dst.Elem(), sf.Type, unsafe.Pointer(newVal.UnsafeAddr()), srcFieldAddr)
+			defer argsPool.Put(arg)
+
+			arg.setField(sf.Name)
+			return f.dCopy(arg, depth+1)
+		}()
+		if err != nil {
+			return err
+		}
+
+		dstVal.SetMapIndex(reflect.ValueOf(sf.name).Convert(dst.Key()), newVal)
+	}
+
+	return nil
+}
+
+// map拷贝到结构体指针, 比如map[string]interface{}里嵌套的map拷贝到*Address字段
+func mapToStructPtr(dst, src reflect.Type) bool {
+	return src.Kind() == reflect.Map && dst.Kind() == reflect.Ptr && dst.Elem().Kind() == reflect.Struct
+}
+
+// 和cpyPtr一样, 分配新的结构体之后按照cpyMapToStruct拷贝
+func (f *dCopy) cpyMapToStructPtr(a *args, depth int) error {
+	if typePtrToValue(a.srcType, a.srcAddr).IsNil() {
+		*(*unsafe.Pointer)(a.dstAddr) = nil
+		f.record(a, ActionNilSource)
+		return nil
+	}
+
+	// 补丁模式下, dst已经指向的结构体直接在原地更新
+	dstPtr := *(*unsafe.Pointer)(a.dstAddr)
+	if !f.ignoreZero || dstPtr == nil {
+		dstPtr = unsafe.Pointer(reflect.New(a.dstType.Elem()).Pointer())
+	}
+
+	arg := newArgs(a, a.dstType.Elem(), a.srcType, dstPtr, a.srcAddr)
+	defer argsPool.Put(arg)
+
+	if err := f.dCopy(arg, depth); err != nil {
+		return err
+	}
+
+	*(*unsafe.Pointer)(a.dstAddr) = dstPtr
+	return nil
+}
+
+// map[string]T拷贝到结构体, key按照FieldMatcher和dst的字段配对
+func (f *dCopy) cpyMapToStruct(a *args, depth int) error {
+	dst := a.dstType
+	src := a.srcType
+
+	if src.Key().Kind() != reflect.String {
+		return f.mismatch(a)
+	}
+
+	srcVal := typePtrToValue(src, a.srcAddr)
+	if srcVal.IsNil() {
+		f.record(a, ActionNilSource)
+		return nil
+	}
+
+	if err := f.callBefore(a); err != nil {
+		return err
+	}
+
+	matcher := f.getMatcher()
+	dstFields := f.getStructFields(dst)
+
+	var matched []bool
+	if f.strict {
+		matched = make([]bool, len(dstFields.fields))
+	}
+
+	// map的key和value不能取地址, 先放到可以取地址的临时变量里
+	srcKey := reflect.New(src.Key()).Elem()
+	srcElem := reflect.New(src.Elem()).Elem()
+
+	iter := srcVal.MapRange()
+	for iter.Next() {
+		srcKey.Set(iter.Key())
+
+		key := matcher.Key(srcKey.String())
+		df, ok := dstFields.lookup(key)
+		// 设置了tag name, 只拷贝有tag的字段
+		if !ok || len(f.tagName) > 0 && !df.tagged {
+			f.unmatchedSrc(a, segment{kind: segKey, key: srcKey}, src.Elem())
+			continue
+		}
+
+		if matched != nil {
+			matched[df.top] = true
+		}
+
+		srcElem.Set(iter.Value())
+		err := f.cpyElem(a, srcKey, df.Type, src.Elem(), add(a.dstAddr, int(df.Offset)), unsafe.Pointer(srcElem.UnsafeAddr()), depth+1, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.unmatchedDst(a, dstFields, matched)
+	return f.callAfter(a)
+}